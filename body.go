@@ -0,0 +1,151 @@
+package ghttp
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/zdz1715/ghttp/encoding"
+)
+
+// RawBody is a request body sent unchanged by Client.Invoke, it is not marshaled by the codec.
+type RawBody struct {
+	Reader io.Reader
+	// ContentType overrides the Content-Type of the client if set.
+	ContentType string
+	// Length is the Content-Length, unknown if <= 0.
+	Length int64
+}
+
+// ContentTyper is implemented by args of Client.Invoke that have their own media type,
+// e.g. jsonpatch.Patch. They are marshaled by the codec of the media type, which is sent as Content-Type.
+type ContentTyper interface {
+	ContentType() string
+}
+
+type requestBody struct {
+	reader          io.Reader
+	contentType     string
+	contentEncoding string
+	length          int64
+	getBody         func() (io.ReadCloser, error)
+}
+
+// newRawBody returns the request body of raw args, ok is false if args should be marshaled by the codec.
+func newRawBody(args any) (body *requestBody, ok bool, err error) {
+	switch v := args.(type) {
+	case *Multipart:
+		body = &requestBody{reader: v.Reader(), contentType: v.ContentType()}
+		if v.replayable() {
+			body.getBody = func() (io.ReadCloser, error) {
+				return v.Reader(), nil
+			}
+		}
+		return body, true, nil
+	case []byte:
+		return &requestBody{reader: bytes.NewReader(v)}, true, nil
+	case string:
+		return &requestBody{reader: strings.NewReader(v)}, true, nil
+	case RawBody:
+		return newRawBody(&v)
+	case *RawBody:
+		if v == nil || v.Reader == nil {
+			return &requestBody{contentType: v.contentType()}, true, nil
+		}
+		if body, _, err = newRawBody(v.Reader); err != nil {
+			return nil, true, err
+		}
+		if v.ContentType != "" {
+			body.contentType = v.ContentType
+		}
+		if v.Length > 0 {
+			body.length = v.Length
+		}
+		return body, true, nil
+	case *bytes.Buffer, *bytes.Reader, *strings.Reader:
+		// http.NewRequest sets ContentLength and GetBody
+		return &requestBody{reader: v.(io.Reader)}, true, nil
+	case io.ReadSeeker:
+		return newSeekerBody(v)
+	case io.Reader:
+		return &requestBody{reader: v}, true, nil
+	}
+	return nil, false, nil
+}
+
+func (r *RawBody) contentType() string {
+	if r == nil {
+		return ""
+	}
+	return r.ContentType
+}
+
+// newSeekerBody can be re-read from the current offset, it is not closed by the client.
+// An io.ReaderAt gets an independent io.SectionReader per GetBody, otherwise GetBody seeks r back,
+// so the bodies returned by concurrent GetBody calls share the offset and must not be read concurrently.
+func newSeekerBody(r io.ReadSeeker) (*requestBody, bool, error) {
+	offset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, true, err
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, true, err
+	}
+	if _, err = r.Seek(offset, io.SeekStart); err != nil {
+		return nil, true, err
+	}
+	length := end - offset
+	if ra, ok := r.(io.ReaderAt); ok {
+		return &requestBody{
+			reader: io.NopCloser(r),
+			length: length,
+			getBody: func() (io.ReadCloser, error) {
+				return io.NopCloser(io.NewSectionReader(ra, offset, length)), nil
+			},
+		}, true, nil
+	}
+	return &requestBody{
+		reader: io.NopCloser(r),
+		length: length,
+		getBody: func() (io.ReadCloser, error) {
+			if _, err := r.Seek(offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+			return io.NopCloser(r), nil
+		},
+	}, true, nil
+}
+
+// newEncoderBody encodes args in a goroutine started by the first Read of the body, GetBody encodes it again.
+func newEncoderBody(codec encoding.StreamCodec, args any) *requestBody {
+	getBody := func() (io.ReadCloser, error) {
+		return newPipeReader(func(w io.Writer) error {
+			return codec.NewEncoder(w).Encode(args)
+		}), nil
+	}
+	reader, _ := getBody()
+	return &requestBody{reader: reader, getBody: getBody}
+}
+
+func (b *requestBody) apply(req *http.Request) {
+	if b.contentType != "" {
+		req.Header.Set("Content-Type", b.contentType)
+	}
+	if b.contentEncoding != "" {
+		req.Header.Set("Content-Encoding", b.contentEncoding)
+	}
+	if b.length > 0 {
+		req.ContentLength = b.length
+	}
+	if b.getBody != nil {
+		req.GetBody = b.getBody
+	}
+}
+
+func (b *requestBody) close() {
+	if rc, ok := b.reader.(io.Closer); ok {
+		_ = rc.Close()
+	}
+}
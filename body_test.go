@@ -0,0 +1,141 @@
+package ghttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestInvokeRawBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "body.txt")
+	if err := os.WriteFile(path, []byte("from file"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tests := []struct {
+		args          any
+		contentType   string
+		contentLength int64
+		getBody       bool
+		want          string
+	}{
+		{
+			args:          []byte(`{"a":1}`),
+			contentType:   "application/json",
+			contentLength: 7,
+			getBody:       true,
+			want:          `{"a":1}`,
+		},
+		{
+			args:          "raw string",
+			contentType:   "application/json",
+			contentLength: 10,
+			getBody:       true,
+			want:          "raw string",
+		},
+		{
+			args:        io.MultiReader(strings.NewReader("multi"), strings.NewReader("reader")),
+			contentType: "application/json",
+			want:        "multireader",
+		},
+		{
+			args:          f,
+			contentType:   "application/json",
+			contentLength: 9,
+			getBody:       true,
+			want:          "from file",
+		},
+		{
+			args: &RawBody{
+				Reader:      io.MultiReader(strings.NewReader("<a/>")),
+				ContentType: "application/xml",
+				Length:      4,
+			},
+			contentType:   "application/xml",
+			contentLength: 4,
+			want:          "<a/>",
+		},
+	}
+
+	for i, v := range tests {
+		client := NewClient(
+			WithEndpoint("http://example.com"),
+			WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				if ct := req.Header.Get("Content-Type"); ct != v.contentType {
+					t.Errorf("index: %d, Content-Type failed: target=%s want=%s", i, ct, v.contentType)
+				}
+				if req.ContentLength != v.contentLength {
+					t.Errorf("index: %d, ContentLength failed: target=%d want=%d", i, req.ContentLength, v.contentLength)
+				}
+				b, _ := io.ReadAll(req.Body)
+				if string(b) != v.want {
+					t.Errorf("index: %d, body failed: target=%s want=%s", i, b, v.want)
+				}
+				if (req.GetBody != nil) != v.getBody {
+					t.Errorf("index: %d, GetBody failed: target=%v want=%v", i, req.GetBody != nil, v.getBody)
+				}
+				if req.GetBody != nil {
+					rc, err := req.GetBody()
+					if err != nil {
+						t.Fatal(err)
+					}
+					b, _ = io.ReadAll(rc)
+					if string(b) != v.want {
+						t.Errorf("index: %d, GetBody() failed: target=%s want=%s", i, b, v.want)
+					}
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     make(http.Header),
+					Body:       io.NopCloser(bytes.NewReader(nil)),
+					Request:    req,
+				}, nil
+			})),
+		)
+		if _, err := client.Invoke(context.Background(), http.MethodPost, "/", v.args, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSeekerBodyGetBody(t *testing.T) {
+	r := strings.NewReader("skip:body")
+	if _, err := r.Seek(5, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	body, _, err := newSeekerBody(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every GetBody of an io.ReaderAt has its own offset
+	first, _ := body.getBody()
+	second, _ := body.getBody()
+	b := make([]byte, 2)
+	if _, err = io.ReadFull(first, b); err != nil {
+		t.Fatal(err)
+	}
+	for i, rc := range []io.ReadCloser{second, first} {
+		target, _ := io.ReadAll(rc)
+		want := []string{"body", "dy"}[i]
+		if string(target) != want {
+			t.Errorf("index: %d, getBody failed: target=%s want=%s", i, target, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package ghttp
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/zdz1715/ghttp/query"
 )
@@ -21,6 +22,10 @@ type CallOptions struct {
 
 	BearerToken string // Bearer Token
 
+	// Progress reports the progress of the request and response bodies of this call
+	Progress         ProgressFunc
+	ProgressInterval time.Duration
+
 	// hooks
 	BeforeHook func(request *http.Request) error
 	AfterHook  func(response *http.Response) error
@@ -60,3 +65,19 @@ func (c *CallOptions) After(response *http.Response) error {
 	}
 	return nil
 }
+
+func (c *CallOptions) progress() (ProgressFunc, time.Duration) {
+	return c.Progress, c.ProgressInterval
+}
+
+// DefaultHeader returns a CallOption which sets the header key to value if it is not set by the caller.
+func DefaultHeader(key, value string) CallOption {
+	return &CallOptions{
+		BeforeHook: func(request *http.Request) error {
+			if request.Header.Get(key) == "" {
+				request.Header.Set(key, value)
+			}
+			return nil
+		},
+	}
+}
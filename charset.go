@@ -0,0 +1,113 @@
+package ghttp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+
+	gencoding "golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+
+	"github.com/zdz1715/ghttp/encoding"
+	"github.com/zdz1715/ghttp/encoding/binary"
+	"github.com/zdz1715/ghttp/encoding/cbor"
+	"github.com/zdz1715/ghttp/encoding/msgpack"
+	"github.com/zdz1715/ghttp/encoding/proto"
+	"github.com/zdz1715/ghttp/encoding/xml"
+)
+
+// binaryCodecs are never transcoded.
+var binaryCodecs = map[string]struct{}{
+	binary.Name:  {},
+	cbor.Name:    {},
+	msgpack.Name: {},
+	proto.Name:   {},
+}
+
+// WithRequestCharset encode the marshaled request body in charset (e.g. GBK, GB18030) instead of UTF-8,
+// and set the charset parameter of Content-Type. Binary codecs are not affected.
+func WithRequestCharset(charset string) ClientOption {
+	return func(c *clientOptions) {
+		c.requestCharset = charset
+	}
+}
+
+// lookupCharset returns the encoding of the charset label, nil if it is UTF-8.
+func lookupCharset(charset string) (gencoding.Encoding, error) {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset: %s", charset)
+	}
+	if name, _ := htmlindex.Name(enc); name == "utf-8" {
+		return nil, nil
+	}
+	return enc, nil
+}
+
+// encodeCharset transcodes the UTF-8 body of codec to charset, and returns contentType with the charset parameter.
+func encodeCharset(codec encoding.Codec, contentType, charset string, body []byte) ([]byte, string, error) {
+	if _, ok := binaryCodecs[codec.Name()]; ok {
+		return body, contentType, nil
+	}
+	enc, err := lookupCharset(charset)
+	if err != nil {
+		return nil, "", fmt.Errorf("request: %w", err)
+	}
+	if enc != nil {
+		if body, err = enc.NewEncoder().Bytes(body); err != nil {
+			return nil, "", fmt.Errorf("request: %w", err)
+		}
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, "", fmt.Errorf("request: %w", err)
+	}
+	params["charset"] = charset
+	return body, mime.FormatMediaType(mediaType, params), nil
+}
+
+// decodeCharset returns the body transcoded to UTF-8 from the charset of Content-Type,
+// the body is returned as is if the charset is not recognized.
+func decodeCharset(codec encoding.Codec, response *http.Response) (io.Reader, error) {
+	if _, ok := binaryCodecs[codec.Name()]; ok {
+		return response.Body, nil
+	}
+	_, params, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	charset := params["charset"]
+	if charset == "" {
+		return response.Body, nil
+	}
+	enc, err := lookupCharset(charset)
+	if err != nil || enc == nil {
+		return response.Body, nil
+	}
+	r := enc.NewDecoder().Reader(response.Body)
+	if codec.Name() == xml.Name {
+		return utf8XMLDeclaration(r)
+	}
+	return r, nil
+}
+
+var xmlDeclarationEncoding = regexp.MustCompile(`^(\s*<\?xml[^>]*?encoding\s*=\s*)["'][^"']*["']`)
+
+// utf8XMLDeclaration sets the encoding of the XML declaration to UTF-8, the charset of Content-Type
+// takes precedence (RFC 7303), otherwise the XML decoder transcodes the body again.
+func utf8XMLDeclaration(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	prefix, err := br.Peek(256)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	end := bytes.Index(prefix, []byte("?>"))
+	if end < 0 || !strings.HasPrefix(strings.TrimSpace(string(prefix)), "<?xml") {
+		return br, nil
+	}
+	declaration := xmlDeclarationEncoding.ReplaceAll(prefix[:end], []byte(`${1}"UTF-8"`))
+	_, _ = br.Discard(end)
+	return io.MultiReader(bytes.NewReader(declaration), br), nil
+}
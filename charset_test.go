@@ -0,0 +1,109 @@
+package ghttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+func TestBindResponseBodyCharset(t *testing.T) {
+	gbk := func(s string) string {
+		b, _ := simplifiedchinese.GBK.NewEncoder().String(s)
+		return b
+	}
+	gb18030 := func(s string) string {
+		b, _ := simplifiedchinese.GB18030.NewEncoder().String(s)
+		return b
+	}
+
+	type reply struct {
+		Name string `json:"name" xml:"name"`
+	}
+
+	tests := []struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			contentType: "application/json; charset=GB18030",
+			body:        gb18030(`{"name":"北京市政务服务"}`),
+			want:        "北京市政务服务",
+		},
+		{
+			contentType: "text/xml; charset=GBK",
+			body:        gbk(`<?xml version="1.0" encoding="GBK"?><reply><name>国家税务总局</name></reply>`),
+			want:        "国家税务总局",
+		},
+		{
+			contentType: "application/xml",
+			body:        gbk(`<?xml version="1.0" encoding="gb2312"?><reply><name>国家税务总局</name></reply>`),
+			want:        "国家税务总局",
+		},
+		{
+			contentType: "application/json; charset=utf-8",
+			body:        `{"name":"上海"}`,
+			want:        "上海",
+		},
+		{
+			// unknown labels are passed through
+			contentType: "application/json; charset=unknown-charset",
+			body:        `{"name":"上海"}`,
+			want:        "上海",
+		},
+		{
+			contentType: "text/plain; charset=binary",
+			body:        `{"name":"上海"}`,
+			want:        "上海",
+		},
+	}
+
+	for i, v := range tests {
+		client := NewClient(
+			WithEndpoint("http://example.com"),
+			WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{v.contentType}},
+					Body:       io.NopCloser(strings.NewReader(v.body)),
+					Request:    req,
+				}, nil
+			})),
+		)
+		var r reply
+		_, err := client.Invoke(context.Background(), http.MethodGet, "/", nil, &r)
+		if err != nil {
+			t.Errorf("index: %d, Invoke() failed: %v", i, err)
+			continue
+		}
+		if r.Name != v.want {
+			t.Errorf("index: %d, reply failed: target=%s want=%s", i, r.Name, v.want)
+		}
+	}
+}
+
+func TestWithRequestCharset(t *testing.T) {
+	client := NewClient(
+		WithEndpoint("http://example.com"),
+		WithRequestCharset("GBK"),
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if ct := req.Header.Get("Content-Type"); ct != "application/json; charset=GBK" {
+				t.Errorf("Content-Type failed: target=%s", ct)
+			}
+			b, _ := io.ReadAll(req.Body)
+			want, _ := simplifiedchinese.GBK.NewEncoder().Bytes([]byte(`{"name":"广州"}`))
+			if !bytes.Equal(b, want) {
+				t.Errorf("body failed: target=%q want=%q", b, want)
+			}
+			return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: req}, nil
+		})),
+	)
+	if _, err := client.Invoke(context.Background(), http.MethodPost, "/", map[string]string{"name": "广州"}, nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -11,8 +11,16 @@ import (
 	"net/http/httptrace"
 	"net/url"
 	"time"
+
+	"github.com/zdz1715/ghttp/encoding"
+	"github.com/zdz1715/ghttp/encoding/binary"
+	"github.com/zdz1715/ghttp/encoding/json"
+	"github.com/zdz1715/ghttp/encoding/text"
 )
 
+// maxDrainSize is the maximum number of bytes read after the reply is decoded to reuse the connection.
+const maxDrainSize = 64 << 10
+
 // ClientOption is HTTP client option.
 type ClientOption func(*clientOptions)
 
@@ -27,6 +35,21 @@ type clientOptions struct {
 	proxy       func(*http.Request) (*url.URL, error)
 	not2xxError func() Not2xxError
 	debug       func() DebugInterface
+
+	progress         ProgressFunc
+	progressInterval time.Duration
+
+	requestEncoding   string
+	compressThreshold int
+
+	streamRequest     bool
+	requestCharset    string
+	acceptNegotiation bool
+	bodySniffing      bool
+	envelope          *Envelope
+
+	registry *CodecRegistry
+	codecs   []encoding.Codec
 }
 
 // WithTransport with http.RoundTrippe.
@@ -92,12 +115,50 @@ func WithDebug(f func() DebugInterface) ClientOption {
 	}
 }
 
+// WithProgress report the progress of request and response bodies,
+// interval is the minimum interval between two reports, default DefaultProgressInterval.
+func WithProgress(f ProgressFunc, interval ...time.Duration) ClientOption {
+	return func(c *clientOptions) {
+		c.progress = f
+		if len(interval) > 0 {
+			c.progressInterval = interval[0]
+		}
+	}
+}
+
+// WithRequestStreaming encode the request body of Invoke directly to the connection if the codec
+// implements encoding.StreamCodec, the body is sent with chunked transfer encoding since its length is unknown.
+// It is not applied if request compression is enabled.
+func WithRequestStreaming(enabled bool) ClientOption {
+	return func(c *clientOptions) {
+		c.streamRequest = enabled
+	}
+}
+
+// WithCodec use codec instead of the registered codec with the same name for this client,
+// e.g. json.NewCodec with different options. Other clients are not affected.
+func WithCodec(codec encoding.Codec) ClientOption {
+	return func(c *clientOptions) {
+		if codec != nil {
+			c.codecs = append(c.codecs, codec)
+		}
+	}
+}
+
+// WithCodecs look up codecs from registry instead of the global registrations,
+// the registry falls back to the global registrations.
+func WithCodecs(registry *CodecRegistry) ClientOption {
+	return func(c *clientOptions) {
+		c.registry = registry
+	}
+}
+
 // Client is an HTTP client.
 type Client struct {
-	opts           clientOptions
-	hc             *http.Client
-	target         *url.URL
-	contentSubType string
+	opts   clientOptions
+	hc     *http.Client
+	target *url.URL
+	codecs *CodecRegistry
 }
 
 func NewClient(opts ...ClientOption) *Client {
@@ -130,7 +191,15 @@ func NewClient(opts ...ClientOption) *Client {
 		hc: &http.Client{
 			Transport: options.transport,
 		},
-		contentSubType: ContentSubtype(options.contentType),
+		codecs: options.registry,
+	}
+
+	// codecs of WithCodec do not change the shared registry
+	if len(options.codecs) > 0 {
+		c.codecs = NewCodecRegistry(options.registry)
+		for _, codec := range options.codecs {
+			c.codecs.Register(codec)
+		}
 	}
 
 	c.SetEndpoint(options.endpoint)
@@ -151,18 +220,26 @@ func (c *Client) Endpoint() string {
 }
 
 func (c *Client) bindNot2xxError(response *http.Response) error {
-	if !Not2xxCode(response.StatusCode) || c.opts.not2xxError == nil {
+	if !Not2xxCode(response.StatusCode) {
 		return nil
 	}
 
 	// new not2xxError
-	not2xxError := c.opts.not2xxError()
+	var not2xxError Not2xxError
+	if c.opts.not2xxError != nil {
+		not2xxError = c.opts.not2xxError()
+	}
+	// application/problem+json is decoded by default
+	if not2xxError == nil && isProblemResponse(response) {
+		not2xxError = &ProblemDetails{}
+	}
 
 	if not2xxError == nil {
 		return nil
 	}
 
-	if err := c.BindResponseBody(response, not2xxError); err != nil {
+	// the error body is not a business envelope
+	if err := c.bindBody(response, not2xxError, false); err != nil {
 		return err
 	}
 
@@ -174,17 +251,86 @@ func (c *Client) bindNot2xxError(response *http.Response) error {
 	}
 }
 
+// BindResponseBody decodes the response body into reply and closes it. If WithEnvelope is set,
+// only the data of the envelope is bound, and a nil reply checks the business code of the body
+// which is kept readable.
 func (c *Client) BindResponseBody(response *http.Response, reply any) error {
+	return c.bindBody(response, reply, c.opts.envelope != nil)
+}
+
+// bindBody binds the response body to reply, the envelope is unwrapped if unwrap is true.
+func (c *Client) bindBody(response *http.Response, reply any, unwrap bool) error {
+	if replies, ok := reply.(*StatusReplies); ok {
+		if reply = replies.match(response.StatusCode); reply == nil && !unwrap && response.Body != nil {
+			// nothing is bound, drain the body so the connection can be reused
+			_, _ = io.CopyN(io.Discard, response.Body, maxDrainSize)
+			return response.Body.Close()
+		}
+	}
 	if reply == nil {
-		return nil
+		if !unwrap || response.Body == nil || isStreamResponse(response) {
+			return nil
+		}
+		// the business code is checked on a copy, the body is kept for the caller
+		body, err := io.ReadAll(response.Body)
+		_ = response.Body.Close()
+		response.Body = io.NopCloser(bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		checked := *response
+		checked.Body = io.NopCloser(bytes.NewReader(body))
+		response = &checked
+	}
+	defer response.Body.Close()
+	codec, _ := c.codecs.CodecForResponse(response)
+	sniffed, ok := sniffedContentType(response)
+	if ok && !isRawReply(reply) {
+		var err error
+		if codec, err = c.sniffedCodec(response, sniffed, reply); err != nil {
+			return err
+		}
+	} else {
+		sniffed = ""
+		// text and binary only take the body as is, e.g. JSON labeled text/plain is bound by the json codec
+		if codec != nil && (codec.Name() == text.Name || codec.Name() == binary.Name) && !isRawReply(reply) {
+			codec = c.codecs.GetCodec(json.Name)
+		}
 	}
-	codec, _ := CodecForResponse(response)
 	if codec == nil {
 		return fmt.Errorf("response: unsupported content type: %s", response.Header.Get("Content-Type"))
 	}
 
-	defer response.Body.Close()
-	body, err := io.ReadAll(response.Body)
+	err := c.decodeResponseBody(codec, response, reply, unwrap)
+	if err != nil && sniffed != "" && !IsBusinessError(err) {
+		return fmt.Errorf("response: decode the body sniffed as %s: %w", sniffed, err)
+	}
+	return err
+}
+
+func (c *Client) decodeResponseBody(codec encoding.Codec, response *http.Response, reply any, unwrap bool) error {
+	r, err := decodeCharset(codec, response)
+	if err != nil {
+		return err
+	}
+	if unwrap {
+		body, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return c.opts.envelope.unwrap(codec, response, body, reply)
+	}
+	if sc, ok := codec.(encoding.StreamCodec); ok {
+		err = sc.NewDecoder(r).Decode(reply)
+		if err == io.EOF {
+			// the body is empty, report it as the codec does, e.g. "unexpected end of JSON input"
+			return codec.Unmarshal(nil, reply)
+		}
+		// drain the rest, so the connection can be reused
+		_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxDrainSize))
+		return err
+	}
+	body, err := io.ReadAll(r)
 	if err != nil {
 		return err
 	}
@@ -202,8 +348,20 @@ func (c *Client) setHeader(req *http.Request) {
 	}
 }
 
+type streamingKey struct{}
+
+// streamingContext marks a long-lived streaming request, the client timeout is not applied
+// and Debug does not read the response body.
+func streamingContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, streamingKey{}, true)
+}
+
+func isStreamingContext(ctx context.Context) bool {
+	return ctx.Value(streamingKey{}) != nil
+}
+
 func (c *Client) setTimeout(ctx context.Context) (context.Context, context.CancelFunc, bool) {
-	if c.opts.timeout > 0 {
+	if c.opts.timeout > 0 && !isStreamingContext(ctx) {
 		// the timeout period of this request will not be overwritten
 		if _, ok := ctx.Deadline(); !ok {
 			var cancel context.CancelFunc
@@ -214,31 +372,73 @@ func (c *Client) setTimeout(ctx context.Context) (context.Context, context.Cance
 	return ctx, func() {}, false
 }
 
-// Invoke makes a rpc call procedure for remote service.
-func (c *Client) Invoke(ctx context.Context, method, path string, args any, reply any, opts ...CallOption) (*http.Response, error) {
-	var (
-		body   io.Reader
-		cancel context.CancelFunc
-	)
-
-	// set timeout, Do() is not set repeatedly and does not trigger defer()
-	ctx, cancel, _ = c.setTimeout(ctx)
-	defer cancel()
+// newRequest returns the request of Invoke, args is marshaled by the codec unless it is a raw body.
+func (c *Client) newRequest(ctx context.Context, method, path string, args any) (*http.Request, error) {
+	// raw request body is sent unchanged
+	rawBody, ok, err := newRawBody(args)
+	if err != nil {
+		return nil, err
+	}
 
 	// marshal request body
-	if args != nil {
-		codec := defaultContentType.Get(c.contentSubType)
+	if !ok && args != nil {
+		contentType := c.opts.contentType
+		if typer, ok := args.(ContentTyper); ok {
+			contentType = typer.ContentType()
+		}
+		codec := c.codecs.GetCodecByContentType(contentType)
 		if codec == nil {
-			return nil, fmt.Errorf("request: unsupported content type: %s", c.opts.contentType)
+			return nil, fmt.Errorf("request: unsupported content type: %s", contentType)
 		}
-		bodyBytes, err := codec.Marshal(args)
-		if err != nil {
-			return nil, err
+		sc, ok := codec.(encoding.StreamCodec)
+		if ok && c.opts.streamRequest && c.opts.requestEncoding == "" && c.opts.requestCharset == "" {
+			rawBody = newEncoderBody(sc, args)
+		} else {
+			bodyBytes, err := codec.Marshal(args)
+			if err != nil {
+				return nil, err
+			}
+			if c.opts.requestCharset != "" {
+				if bodyBytes, contentType, err = encodeCharset(codec, contentType, c.opts.requestCharset, bodyBytes); err != nil {
+					return nil, err
+				}
+			}
+			rawBody = &requestBody{}
+			if c.opts.requestEncoding != "" && len(bodyBytes) >= c.opts.compressThreshold {
+				if bodyBytes, err = compress(c.opts.requestEncoding, bodyBytes); err != nil {
+					return nil, err
+				}
+				rawBody.contentEncoding = c.opts.requestEncoding
+			}
+			rawBody.reader = bytes.NewBuffer(bodyBytes)
 		}
-		body = bytes.NewBuffer(bodyBytes)
+		if contentType != c.opts.contentType {
+			rawBody.contentType = contentType
+		}
+	}
+
+	var body io.Reader
+	if rawBody != nil {
+		body = rawBody.reader
 	}
 
 	req, err := http.NewRequestWithContext(ctx, method, FullPath(c.Endpoint(), path), body)
+	if err != nil {
+		if rawBody != nil {
+			rawBody.close()
+		}
+		return nil, err
+	}
+
+	if rawBody != nil {
+		rawBody.apply(req)
+	}
+	return req, nil
+}
+
+// Invoke makes a rpc call procedure for remote service.
+func (c *Client) Invoke(ctx context.Context, method, path string, args any, reply any, opts ...CallOption) (*http.Response, error) {
+	req, err := c.newRequest(ctx, method, path, args)
 	if err != nil {
 		return nil, err
 	}
@@ -265,6 +465,7 @@ func (c *Client) Do(req *http.Request, opts ...CallOption) (*http.Response, erro
 	// apply CallOption before
 	for _, callOpt := range opts {
 		if err = callOpt.Before(req); err != nil {
+			closeRequestBody(req)
 			return nil, err
 		}
 	}
@@ -275,20 +476,36 @@ func (c *Client) Do(req *http.Request, opts ...CallOption) (*http.Response, erro
 	if newUrl != fullPath {
 		nu, err := url.Parse(newUrl)
 		if err != nil {
+			closeRequestBody(req)
 			return nil, err
 		}
 		req.URL = nu
 	}
 
-	// set timeout
+	// the codecs of the client are used by Debug and the stream decoders of the response
+	if c.codecs != nil {
+		req = req.WithContext(context.WithValue(req.Context(), codecsKey{}, c.codecs))
+	}
+
+	// set timeout, it is canceled when the response body is closed
 	ctx, cancel, ok := c.setTimeout(req.Context())
 	if ok {
-		defer cancel()
 		req = req.WithContext(ctx)
 	}
 
-	// set  header
+	// set  header, Accept is negotiated only if it is not set by the caller
+	negotiate := c.opts.acceptNegotiation && req.Header.Get("Accept") == ""
 	c.setHeader(req)
+	if negotiate {
+		req.Header.Set("Accept", c.acceptHeader())
+	}
+
+	// the response is decompressed if Accept-Encoding is not set by the caller
+	decompress := req.Header.Get("Accept-Encoding") == ""
+	if decompress {
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+	}
+
 	var debugHook DebugInterface
 
 	if c.opts.debug != nil {
@@ -303,11 +520,36 @@ func (c *Client) Do(req *http.Request, opts ...CallOption) (*http.Response, erro
 		}
 	}
 
-	response, err := c.hc.Do(req)
+	// the request sent reports progress, debug reads the original body
+	sendReq := req
+	reporters := c.progressReporters(opts)
+	if len(reporters) > 0 {
+		sendReq = trackUpload(req, reporters)
+	}
+
+	response, err := c.hc.Do(sendReq)
+	if err == nil && negotiate {
+		req, response, err = c.retryNotAcceptable(req, response, reporters)
+	}
 	if err != nil {
+		cancel()
 		return nil, err
 	}
 
+	if decompress {
+		decompressResponse(response)
+	}
+
+	if ok {
+		response.Body = &cancelReadCloser{ReadCloser: response.Body, cancel: cancel}
+	}
+
+	if len(reporters) > 0 {
+		trackDownload(response, reporters)
+	}
+
+	c.sniffResponse(response)
+
 	if debugHook != nil {
 		debugHook.After(req, response)
 	}
@@ -315,13 +557,38 @@ func (c *Client) Do(req *http.Request, opts ...CallOption) (*http.Response, erro
 	// apply CallOption After
 	for _, callOpt := range opts {
 		if err = callOpt.After(response); err != nil {
+			_ = response.Body.Close()
 			return nil, err
 		}
 	}
 
 	if err = c.bindNot2xxError(response); err != nil {
+		_ = response.Body.Close()
 		return nil, err
 	}
 
 	return response, nil
 }
+
+// closeRequestBody closes the body of a request which is not sent, like http.Client.Do does on errors.
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+}
+
+// cancelReadCloser cancels the context of the request when the body is closed.
+type cancelReadCloser struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelReadCloser) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+func (c *cancelReadCloser) unwrap() io.ReadCloser {
+	return c.ReadCloser
+}
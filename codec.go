@@ -2,6 +2,7 @@ package ghttp
 
 import (
 	"net/http"
+	"strings"
 	"sync"
 
 	"github.com/zdz1715/ghttp/encoding/yaml"
@@ -9,87 +10,327 @@ import (
 	"github.com/zdz1715/ghttp/encoding/xml"
 
 	"github.com/zdz1715/ghttp/encoding"
+	"github.com/zdz1715/ghttp/encoding/binary"
+	"github.com/zdz1715/ghttp/encoding/cbor"
+	"github.com/zdz1715/ghttp/encoding/csv"
 	"github.com/zdz1715/ghttp/encoding/json"
+	"github.com/zdz1715/ghttp/encoding/jsonpatch"
+	"github.com/zdz1715/ghttp/encoding/mergepatch"
+	"github.com/zdz1715/ghttp/encoding/msgpack"
 	"github.com/zdz1715/ghttp/encoding/proto"
+	"github.com/zdz1715/ghttp/encoding/text"
+	"github.com/zdz1715/ghttp/encoding/toml"
 )
 
 var defaultContentType = newDefaultContentType()
 
 type contentType struct {
 	subType map[string]string
-	mu      sync.RWMutex
+	// accept maps the media types generated in Accept to codec names.
+	accept map[string]string
+	mu     sync.RWMutex
 }
 
 func newDefaultContentType() *contentType {
 	return &contentType{
 		subType: map[string]string{
-			// default: json
-			"*": json.Name,
-
-			"json":       json.Name,
-			"x-protobuf": proto.Name,
-			"xml":        xml.Name,
-			"x-yaml":     yaml.Name,
-			"yaml":       yaml.Name,
+			"json":         json.Name,
+			"x-protobuf":   proto.Name,
+			"xml":          xml.Name,
+			"x-yaml":       yaml.Name,
+			"yaml":         yaml.Name,
+			"msgpack":      msgpack.Name,
+			"x-msgpack":    msgpack.Name,
+			"cbor":         cbor.Name,
+			"csv":          csv.Name,
+			"plain":        text.Name,
+			"html":         text.Name,
+			"octet-stream": binary.Name,
+			"toml":         toml.Name,
+
+			// full types are preferred to the +json suffix
+			jsonpatch.ContentType:  jsonpatch.Name,
+			mergepatch.ContentType: mergepatch.Name,
+		},
+		accept: map[string]string{
+			"application/json":         json.Name,
+			"application/xml":          xml.Name,
+			"application/yaml":         yaml.Name,
+			"application/x-protobuf":   proto.Name,
+			"application/msgpack":      msgpack.Name,
+			"application/cbor":         cbor.Name,
+			"application/toml":         toml.Name,
+			"application/octet-stream": binary.Name,
+			"text/csv":                 csv.Name,
+			"text/plain":               text.Name,
 		},
 	}
 }
 
-func (c *contentType) Set(name string, cname string) {
+// register maps the key of contentType to cname, and records the media type for Accept.
+func (c *contentType) register(contentType string, cname string, key func(string) (MediaType, string, bool)) {
+	m, k, ok := key(contentType)
+	if !ok {
+		return
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.subType[name] = cname
+	c.subType[k] = cname
+	if m.Type != "*" && m.Subtype != "*" {
+		c.accept[m.FullType()] = cname
+	}
+}
+
+// Name returns the codec name of the content subtype.
+func (c *contentType) Name(name string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cname, ok := c.subType[name]
+	return cname, ok
+}
+
+// subtypeKey returns the content subtype as the registry key, a media range (e.g. text/*) is kept whole.
+func subtypeKey(contentType string) (MediaType, string, bool) {
+	m, err := ParseMediaType(contentType)
+	if err != nil {
+		return m, "", false
+	}
+	if m.Type == "*" || m.Subtype == "*" {
+		return m, m.FullType(), true
+	}
+	return m, ContentSubtype(contentType), true
 }
 
-func (c *contentType) Get(name string) encoding.Codec {
-	return encoding.GetCodec(c.subType[name])
+// fullTypeKey returns type/subtype as the registry key.
+func fullTypeKey(mediaType string) (MediaType, string, bool) {
+	m, err := ParseMediaType(mediaType)
+	if err != nil {
+		return m, "", false
+	}
+	return m, m.FullType(), true
 }
 
+// RegisterCodecNameByContentType maps the subtype of contentType to the codec name,
+// e.g. application/vnd.api+json is mapped by json.
 func RegisterCodecNameByContentType(contentType string, name string) {
 	if name == "" {
 		return
 	}
-	defaultContentType.Set(ContentSubtype(contentType), name)
+	defaultContentType.register(contentType, name, subtypeKey)
 }
 
+// RegisterCodecByContentType registers codec and maps the subtype of contentType to it.
 func RegisterCodecByContentType(contentType string, codec encoding.Codec) {
 	if codec == nil {
 		return
 	}
 	encoding.RegisterCodec(codec)
-	defaultContentType.Set(ContentSubtype(contentType), codec.Name())
+	defaultContentType.register(contentType, codec.Name(), subtypeKey)
 }
 
+// RegisterCodecByMediaType registers codec and maps the exact media type (type/subtype) to it,
+// which is preferred to the subtype and suffix mappings. mediaType can be a range such as text/*.
+func RegisterCodecByMediaType(mediaType string, codec encoding.Codec) {
+	if codec == nil {
+		return
+	}
+	encoding.RegisterCodec(codec)
+	defaultContentType.register(mediaType, codec.Name(), fullTypeKey)
+}
+
+// GetCodecByContentType returns the codec of contentType, looked up by the full type, subtype,
+// structured syntax suffix and then the media ranges type/* and */*.
 func GetCodecByContentType(contentType string) encoding.Codec {
-	return defaultContentType.Get(ContentSubtype(contentType))
+	return (*CodecRegistry)(nil).GetCodecByContentType(contentType)
 }
 
 // CodecForRequest get encoding.Codec via http.Request
 func CodecForRequest(r *http.Request, name ...string) (encoding.Codec, bool) {
+	return codecForHeader(r.Header, name, GetCodecByContentType, encoding.GetCodec)
+}
+
+// CodecForResponse get encoding.Codec via http.Response
+func CodecForResponse(r *http.Response, name ...string) (encoding.Codec, bool) {
+	return codecForHeader(r.Header, name, GetCodecByContentType, encoding.GetCodec)
+}
+
+// codecForHeader returns the codec of the first content type in the header (default Content-Type),
+// ok is false if json is returned as the fallback.
+func codecForHeader(header http.Header, name []string, byContentType, byName func(string) encoding.Codec) (encoding.Codec, bool) {
 	headerName := "Content-Type"
 	if len(name) > 0 && name[0] != "" {
 		headerName = name[0]
 	}
-	for _, accept := range r.Header[headerName] {
-		codec := GetCodecByContentType(accept)
-		if codec != nil {
-			return codec, true
+	for _, value := range header[headerName] {
+		for _, m := range ParseAccept(value) {
+			if codec := byContentType(m.String()); codec != nil {
+				return codec, true
+			}
 		}
 	}
-	return encoding.GetCodec(json.Name), false
+	return byName(json.Name), false
 }
 
-// CodecForResponse get encoding.Codec via http.Response
-func CodecForResponse(r *http.Response, name ...string) (encoding.Codec, bool) {
-	headerName := "Content-Type"
-	if len(name) > 0 && name[0] != "" {
-		headerName = name[0]
+type codecsKey struct{}
+
+// codecsFromRequest returns the registry of the client which sent req, nil is the global registrations.
+func codecsFromRequest(req *http.Request) *CodecRegistry {
+	if req == nil {
+		return nil
+	}
+	registry, _ := req.Context().Value(codecsKey{}).(*CodecRegistry)
+	return registry
+}
+
+// CodecRegistry is a set of codecs and content type mappings, it is safe for concurrent use.
+// Lookups fall back to the parent registry, or to the global registrations if there is no parent,
+// so a client using its own registry is not affected by, and does not affect, other clients.
+type CodecRegistry struct {
+	parent  *CodecRegistry
+	mu      sync.RWMutex
+	codecs  map[string]encoding.Codec
+	subType map[string]string
+	accept  map[string]string
+}
+
+// NewCodecRegistry returns an empty registry that falls back to parent, or to the global registrations.
+func NewCodecRegistry(parent ...*CodecRegistry) *CodecRegistry {
+	r := &CodecRegistry{
+		codecs:  make(map[string]encoding.Codec),
+		subType: make(map[string]string),
+		accept:  make(map[string]string),
+	}
+	if len(parent) > 0 {
+		r.parent = parent[0]
+	}
+	return r
+}
+
+// Register registers codec by its name.
+func (r *CodecRegistry) Register(codec encoding.Codec) {
+	if codec == nil || codec.Name() == "" {
+		return
 	}
-	for _, accept := range r.Header[headerName] {
-		codec := GetCodecByContentType(accept)
-		if codec != nil {
-			return codec, true
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codecs[strings.ToLower(codec.Name())] = codec
+}
+
+// RegisterNameByContentType maps the subtype of contentType to the codec name.
+func (r *CodecRegistry) RegisterNameByContentType(contentType string, name string) {
+	if name == "" {
+		return
+	}
+	r.register(contentType, name, subtypeKey)
+}
+
+// RegisterByMediaType registers codec and maps the exact media type (type/subtype) to it.
+func (r *CodecRegistry) RegisterByMediaType(mediaType string, codec encoding.Codec) {
+	if codec == nil {
+		return
+	}
+	r.Register(codec)
+	r.register(mediaType, codec.Name(), fullTypeKey)
+}
+
+func (r *CodecRegistry) register(contentType string, name string, key func(string) (MediaType, string, bool)) {
+	m, k, ok := key(contentType)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subType[k] = name
+	if m.Type != "*" && m.Subtype != "*" {
+		r.accept[m.FullType()] = name
+	}
+}
+
+// RegisterByContentType registers codec and maps the subtype of contentType to it.
+func (r *CodecRegistry) RegisterByContentType(contentType string, codec encoding.Codec) {
+	if codec == nil {
+		return
+	}
+	r.Register(codec)
+	r.RegisterNameByContentType(contentType, codec.Name())
+}
+
+// GetCodec returns the codec registered by name.
+func (r *CodecRegistry) GetCodec(name string) encoding.Codec {
+	if r == nil {
+		return encoding.GetCodec(name)
+	}
+	r.mu.RLock()
+	codec, ok := r.codecs[name]
+	r.mu.RUnlock()
+	if ok {
+		return codec
+	}
+	return r.parent.GetCodec(name)
+}
+
+// GetCodecByContentType returns the codec of contentType like GetCodecByContentType, the codec name
+// mapped by any registry is looked up from this registry, so a codec can be replaced without remapping.
+func (r *CodecRegistry) GetCodecByContentType(contentType string) encoding.Codec {
+	m, err := ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+	for _, key := range m.lookupKeys() {
+		if name, ok := r.codecName(key); ok {
+			return r.GetCodec(name)
 		}
 	}
-	return encoding.GetCodec(json.Name), false
+	return nil
+}
+
+func (r *CodecRegistry) codecName(subType string) (string, bool) {
+	if r == nil {
+		return defaultContentType.Name(subType)
+	}
+	r.mu.RLock()
+	name, ok := r.subType[subType]
+	r.mu.RUnlock()
+	if ok {
+		return name, true
+	}
+	return r.parent.codecName(subType)
+}
+
+// acceptTypes returns the media types recorded for Accept whose codec is registered,
+// the registrations of this registry take precedence.
+func (r *CodecRegistry) acceptTypes() map[string]string {
+	types := make(map[string]string)
+	for reg := r; ; reg = reg.parent {
+		mu, accept := &defaultContentType.mu, defaultContentType.accept
+		if reg != nil {
+			mu, accept = &reg.mu, reg.accept
+		}
+		mu.RLock()
+		for mediaType, name := range accept {
+			if _, ok := types[mediaType]; !ok {
+				types[mediaType] = name
+			}
+		}
+		mu.RUnlock()
+		if reg == nil {
+			break
+		}
+	}
+	for mediaType, name := range types {
+		if r.GetCodec(name) == nil {
+			delete(types, mediaType)
+		}
+	}
+	return types
+}
+
+// CodecForRequest get encoding.Codec via http.Request from the registry.
+func (r *CodecRegistry) CodecForRequest(req *http.Request, name ...string) (encoding.Codec, bool) {
+	return codecForHeader(req.Header, name, r.GetCodecByContentType, r.GetCodec)
+}
+
+// CodecForResponse get encoding.Codec via http.Response from the registry.
+func (r *CodecRegistry) CodecForResponse(response *http.Response, name ...string) (encoding.Codec, bool) {
+	return codecForHeader(response.Header, name, r.GetCodecByContentType, r.GetCodec)
 }
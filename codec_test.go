@@ -1,6 +1,22 @@
 package ghttp
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	gojson "encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zdz1715/ghttp/encoding"
+	"github.com/zdz1715/ghttp/encoding/json"
+	"github.com/zdz1715/ghttp/encoding/jsonpatch"
+	"github.com/zdz1715/ghttp/encoding/mergepatch"
+)
 
 func TestGetCodecByContentType(t *testing.T) {
 	tests := []struct {
@@ -27,6 +43,46 @@ func TestGetCodecByContentType(t *testing.T) {
 			contentType: "application/vnd.api+json",
 			want:        "json",
 		},
+		{
+			contentType: "application/msgpack",
+			want:        "msgpack",
+		},
+		{
+			contentType: "application/x-msgpack",
+			want:        "msgpack",
+		},
+		{
+			contentType: "application/cbor",
+			want:        "cbor",
+		},
+		{
+			contentType: "text/csv; charset=utf-8",
+			want:        "csv",
+		},
+		{
+			contentType: "application/toml",
+			want:        "toml",
+		},
+		{
+			contentType: "application/json-patch+json",
+			want:        "json-patch",
+		},
+		{
+			contentType: "application/merge-patch+json; charset=utf-8",
+			want:        "merge-patch",
+		},
+		{
+			contentType: "text/plain; charset=utf-8",
+			want:        "text",
+		},
+		{
+			contentType: "text/html",
+			want:        "text",
+		},
+		{
+			contentType: "application/octet-stream",
+			want:        "binary",
+		},
 		{
 			contentType: "application/json; charset=utf-8",
 			want:        "json",
@@ -35,12 +91,594 @@ func TestGetCodecByContentType(t *testing.T) {
 			contentType: "application/vnd.docker.distribution.manifest.v2+json; charset=utf-8",
 			want:        "json",
 		},
+		{
+			// wildcards are only used by Accept, no codec is registered for them
+			contentType: "*/*",
+			want:        "",
+		},
+		{
+			contentType: "application/unknown",
+			want:        "",
+		},
+	}
+
+	for i, v := range tests {
+		var target string
+		if codec := GetCodecByContentType(v.contentType); codec != nil {
+			target = codec.Name()
+		}
+		if target != v.want {
+			t.Errorf("index: %d, GetCodecByContentType() failed: target=%s want=%s", i, target, v.want)
+		}
+	}
+}
+
+func TestMsgpackFormatIndent(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+		Age  int    `msgpack:"user_age" json:"age"`
+	}
+
+	codec := GetCodecByContentType("application/msgpack")
+	data, err := codec.Marshal(user{Name: "linda", Age: 18})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var u user
+	if err = codec.Unmarshal(data, &u); err != nil || u.Name != "linda" || u.Age != 18 {
+		t.Errorf("msgpack Unmarshal() failed: %+v, %v", u, err)
+	}
+
+	result, err := formatIndent(codec, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n    \"name\": \"linda\",\n    \"user_age\": 18\n}"
+	if strings.TrimSpace(string(result)) != want {
+		t.Errorf("formatIndent() failed: target=%s want=%s", result, want)
+	}
+}
+
+func TestCborCodec(t *testing.T) {
+	type reading struct {
+		Sensor string    `cbor:"s"`
+		Value  float64   `json:"v"`
+		At     time.Time `cbor:"t"`
+	}
+
+	at := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	codec := GetCodecByContentType("application/cbor")
+
+	// deterministic encoding, map keys are sorted
+	a, _ := codec.Marshal(map[string]int{"b": 2, "a": 1, "c": 3})
+	b, _ := codec.Marshal(map[string]int{"c": 3, "a": 1, "b": 2})
+	if !bytes.Equal(a, b) {
+		t.Errorf("cbor Marshal() is not deterministic: %x != %x", a, b)
+	}
+
+	data, err := codec.Marshal(reading{Sensor: "temp", Value: 1.5, At: at})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r reading
+	if err = codec.Unmarshal(data, &r); err != nil || r.Sensor != "temp" || r.Value != 1.5 || !r.At.Equal(at) {
+		t.Errorf("cbor Unmarshal() failed: %+v, %v", r, err)
+	}
+
+	result, err := formatIndent(codec, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"s": "temp", "t": 0("2024-01-02T03:04:05Z"), "v": 1.5}`
+	if string(result) != want {
+		t.Errorf("formatIndent() failed: target=%s want=%s", result, want)
+	}
+}
+
+func TestCsvCodec(t *testing.T) {
+	type row struct {
+		ID      int       `csv:"id"`
+		Name    string    `csv:"name"`
+		Score   *float64  `csv:"score"`
+		Created time.Time `csv:"created"`
+		Ignore  string    `csv:"-"`
+	}
+
+	codec := GetCodecByContentType("text/csv")
+	data := "\ufeffname,id,created,score,unknown\nlinda,1,2024-01-02T03:04:05Z,9.5,x\nliming,2,,,y\n"
+
+	var rows []*row
+	if err := codec.Unmarshal([]byte(data), &rows); err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Name != "linda" || rows[0].ID != 1 || *rows[0].Score != 9.5 ||
+		rows[0].Created.Year() != 2024 || rows[1].ID != 2 || rows[1].Score != nil {
+		t.Errorf("csv Unmarshal() failed: %+v %+v", rows[0], rows[1])
+	}
+
+	b, err := codec.Marshal(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "id,name,score,created\n1,linda,9.5,2024-01-02T03:04:05Z\n2,liming,,\n"
+	if string(b) != want {
+		t.Errorf("csv Marshal() failed: target=%q want=%q", b, want)
+	}
+
+	result, err := formatIndent(codec, b)
+	if err != nil || string(result) != want {
+		t.Errorf("formatIndent() failed: target=%q want=%q, %v", result, want, err)
+	}
+}
+
+func TestInvokeTextAndBinary(t *testing.T) {
+	var stringReply string
+	var bytesReply []byte
+	var bufferReply bytes.Buffer
+	var timeReply time.Time
+	var structReply struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		contentType string
+		body        string
+		reply       any
+		target      func() string
+	}{
+		{
+			contentType: "text/plain; charset=utf-8",
+			body:        "README content",
+			reply:       &stringReply,
+			target:      func() string { return stringReply },
+		},
+		{
+			contentType: "text/html",
+			body:        "<html></html>",
+			reply:       &bufferReply,
+			target:      func() string { return bufferReply.String() },
+		},
+		{
+			contentType: "text/plain",
+			body:        "2024-01-02T03:04:05Z",
+			reply:       &timeReply,
+			target:      func() string { return timeReply.Format(time.RFC3339) },
+		},
+		{
+			// not a raw reply, bound by the json codec
+			contentType: "text/plain; charset=utf-8",
+			body:        `{"name":"ghttp"}`,
+			reply:       &structReply,
+			target: func() string {
+				b, _ := gojson.Marshal(structReply)
+				return string(b)
+			},
+		},
+		{
+			contentType: "application/octet-stream",
+			body:        "\x00\x01\x02",
+			reply:       &bytesReply,
+			target:      func() string { return string(bytesReply) },
+		},
+	}
+
+	for i, v := range tests {
+		client := NewClient(
+			WithEndpoint("http://example.com"),
+			WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{v.contentType}},
+					Body:       io.NopCloser(strings.NewReader(v.body)),
+					Request:    req,
+				}, nil
+			})),
+		)
+		if _, err := client.Invoke(context.Background(), http.MethodGet, "/raw", nil, v.reply); err != nil {
+			t.Fatalf("index: %d, Invoke() failed: %s", i, err)
+		}
+		if target := v.target(); target != v.body {
+			t.Errorf("index: %d, reply failed: target=%q want=%q", i, target, v.body)
+		}
+	}
+
+	codec := GetCodecByContentType("application/octet-stream")
+	if _, err := codec.Marshal(map[string]int{}); err == nil {
+		t.Errorf("binary Marshal() failed: want unsupported type error")
+	}
+	result, _ := formatIndent(codec, []byte{0, 1, 2})
+	if string(result) != "[3 bytes binary data]" {
+		t.Errorf("formatIndent() failed: target=%s", result)
+	}
+}
+
+func TestStreamCodec(t *testing.T) {
+	type user struct {
+		Name string `json:"name" xml:"name" yaml:"name"`
+		Age  int    `json:"age" xml:"age" yaml:"age"`
+	}
+
+	tests := []struct {
+		contentType string
+	}{
+		{contentType: "application/json"},
+		{contentType: "application/xml"},
+		{contentType: "application/yaml"},
+		{contentType: "application/msgpack"},
+		{contentType: "application/cbor"},
+		{contentType: "application/toml"},
+	}
+
+	for i, v := range tests {
+		codec, ok := GetCodecByContentType(v.contentType).(encoding.StreamCodec)
+		if !ok {
+			t.Fatalf("index: %d, %s is not a StreamCodec", i, v.contentType)
+		}
+		in := user{Name: "linda", Age: 18}
+
+		var buf bytes.Buffer
+		if err := codec.NewEncoder(&buf).Encode(&in); err != nil {
+			t.Fatalf("index: %d, Encode() failed: %s", i, err)
+		}
+		var out user
+		if err := codec.Unmarshal(buf.Bytes(), &out); err != nil || out != in {
+			t.Errorf("index: %d, Unmarshal() failed: target=%+v want=%+v, %v", i, out, in, err)
+		}
+		out = user{}
+		if err := codec.NewDecoder(&buf).Decode(&out); err != nil || out != in {
+			t.Errorf("index: %d, Decode() failed: target=%+v want=%+v, %v", i, out, in, err)
+		}
+	}
+}
+
+func TestInvokeStreamCodec(t *testing.T) {
+	client := NewClient(
+		WithEndpoint("http://example.com"),
+		WithContentType("application/json"),
+		WithRequestStreaming(true),
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			if req.ContentLength != 0 {
+				t.Errorf("ContentLength failed: target=%d want=0", req.ContentLength)
+			}
+			body, _ := io.ReadAll(req.Body)
+			rc, err := req.GetBody()
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, _ := io.ReadAll(rc)
+			if string(body) != "{\"name\":\"linda\"}\n" || string(again) != string(body) {
+				t.Errorf("request body failed: target=%q again=%q", body, again)
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/octet-stream"}},
+				Body:       io.NopCloser(strings.NewReader("large file")),
+				Request:    req,
+			}, nil
+		})),
+	)
+
+	var reply bytes.Buffer
+	if _, err := client.Invoke(context.Background(), http.MethodPut, "/files", map[string]string{"name": "linda"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.String() != "large file" {
+		t.Errorf("reply failed: target=%s want=large file", reply.String())
+	}
+}
+
+func TestJsonDecoderTrailingData(t *testing.T) {
+	codec := encoding.GetCodec(json.Name).(encoding.StreamCodec)
+	tests := []struct {
+		data    string
+		wantErr bool
+	}{
+		{data: `{"name":"linda"}`},
+		{data: "{\"name\":\"linda\"}\n  "},
+		{data: `{"name":"linda"} garbage`, wantErr: true},
+		{data: `{"name":"linda"}{}`, wantErr: true},
+	}
+	for i, v := range tests {
+		var out map[string]string
+		err := codec.NewDecoder(strings.NewReader(v.data)).Decode(&out)
+		if (err != nil) != v.wantErr || out["name"] != "linda" {
+			t.Errorf("index: %d, Decode() failed: target=%v err=%v wantErr=%v", i, out, err, v.wantErr)
+		}
+	}
+}
+
+type recordMarshaler struct {
+	marshaled *int32
+}
+
+func (r recordMarshaler) MarshalJSON() ([]byte, error) {
+	atomic.AddInt32(r.marshaled, 1)
+	return []byte("{}"), nil
+}
+
+func TestInvokeEmptyBody(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		want       string
+	}{
+		{statusCode: http.StatusOK, want: "unexpected end of JSON input"},
+		{statusCode: http.StatusNoContent, want: "unexpected end of JSON input"},
+	}
+
+	for i, v := range tests {
+		client := NewClient(
+			WithEndpoint("http://example.com"),
+			WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: v.statusCode,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       http.NoBody,
+					Request:    req,
+				}, nil
+			})),
+		)
+		var reply map[string]string
+		_, err := client.Invoke(context.Background(), http.MethodGet, "/", nil, &reply)
+		if err == nil || err.Error() != v.want {
+			t.Errorf("index: %d, Invoke() failed: target=%v want=%s", i, err, v.want)
+		}
+	}
+}
+
+func TestInvokeStreamCodecNotSent(t *testing.T) {
+	client := NewClient(
+		WithEndpoint("http://example.com"),
+		WithRequestStreaming(true),
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			t.Error("the request is sent")
+			return nil, io.EOF
+		})),
+	)
+
+	var marshaled int32
+	hookErr := errors.New("no token")
+	_, err := client.Invoke(context.Background(), http.MethodPost, "/", recordMarshaler{marshaled: &marshaled}, nil, &CallOptions{
+		BeforeHook: func(request *http.Request) error {
+			return hookErr
+		},
+	})
+	time.Sleep(10 * time.Millisecond)
+	if !errors.Is(err, hookErr) || atomic.LoadInt32(&marshaled) != 0 {
+		t.Errorf("Invoke() failed: marshaled=%d err=%v", marshaled, err)
+	}
+}
+
+func TestWithCodec(t *testing.T) {
+	body := `{"id":12345678901234567890,"name":"linda"}`
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(body)),
+			Request:    req,
+		}, nil
+	})
+
+	opts := json.DefaultOptions()
+	opts.UseNumber = true
+	numberClient := NewClient(WithEndpoint("http://example.com"), WithTransport(transport), WithCodec(json.NewCodec(opts)))
+	opts.DisallowUnknownFields = true
+	strictClient := NewClient(WithEndpoint("http://example.com"), WithTransport(transport), WithCodec(json.NewCodec(opts)))
+	defaultClient := NewClient(WithEndpoint("http://example.com"), WithTransport(transport))
+
+	var reply map[string]any
+	if _, err := numberClient.Invoke(context.Background(), http.MethodGet, "/", nil, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := reply["id"].(gojson.Number); !ok || id.String() != "12345678901234567890" {
+		t.Errorf("UseNumber failed: target=%#v", reply["id"])
+	}
+
+	reply = nil
+	if _, err := defaultClient.Invoke(context.Background(), http.MethodGet, "/", nil, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := reply["id"].(float64); !ok {
+		t.Errorf("default client failed: target=%#v want float64", reply["id"])
+	}
+
+	var user struct {
+		Name string `json:"name"`
+	}
+	if _, err := strictClient.Invoke(context.Background(), http.MethodGet, "/", nil, &user); err == nil {
+		t.Errorf("DisallowUnknownFields failed: want unknown field error")
+	}
+	if _, err := numberClient.Invoke(context.Background(), http.MethodGet, "/", nil, &user); err != nil || user.Name != "linda" {
+		t.Errorf("non-strict client failed: %v", err)
+	}
+
+	codec := json.NewCodec(opts)
+	if err := codec.Unmarshal([]byte(`{"name":"linda"} {}`), &user); err == nil {
+		t.Errorf("Unmarshal() failed: want error after top-level value")
+	}
+}
+
+type upperCodec struct{}
+
+func (upperCodec) Marshal(v interface{}) ([]byte, error) {
+	return []byte(strings.ToUpper(v.(string))), nil
+}
+
+func (upperCodec) Unmarshal(data []byte, v interface{}) error {
+	switch t := v.(type) {
+	case *string:
+		*t = strings.ToUpper(string(data))
+	case *interface{}:
+		*t = strings.ToUpper(string(data))
+	}
+	return nil
+}
+
+func (upperCodec) Name() string {
+	return "upper"
+}
+
+func TestCodecRegistry(t *testing.T) {
+	parent := NewCodecRegistry()
+	parent.RegisterByContentType("text/x-upper", upperCodec{})
+	registry := NewCodecRegistry(parent)
+	registry.RegisterNameByContentType("application/json", "upper")
+
+	tests := []struct {
+		registry    *CodecRegistry
+		contentType string
+		want        string
+	}{
+		{registry: parent, contentType: "text/x-upper", want: "upper"},
+		{registry: parent, contentType: "application/json", want: "json"},
+		{registry: registry, contentType: "text/x-upper", want: "upper"},
+		{registry: registry, contentType: "application/json", want: "upper"},
+		{registry: registry, contentType: "application/xml", want: "xml"},
+		{registry: nil, contentType: "application/json", want: "json"},
+		{registry: nil, contentType: "text/x-upper", want: ""},
+	}
+	for i, v := range tests {
+		var target string
+		if codec := v.registry.GetCodecByContentType(v.contentType); codec != nil {
+			target = codec.Name()
+		}
+		if target != v.want {
+			t.Errorf("index: %d, GetCodecByContentType() failed: target=%s want=%s", i, target, v.want)
+		}
+	}
+	if GetCodecByContentType("text/x-upper") != nil {
+		t.Errorf("registry leaks into the global registrations")
+	}
+
+	response := &http.Response{Header: http.Header{"Content-Type": []string{"application/unknown"}}}
+	if codec, ok := registry.CodecForResponse(response); ok || codec.Name() != "json" {
+		t.Errorf("CodecForResponse() failed: target=%s, %v want=json, false", codec.Name(), ok)
+	}
+
+	var debug bytes.Buffer
+	client := NewClient(
+		WithEndpoint("http://example.com"),
+		WithCodecs(parent),
+		WithDebug(func() DebugInterface {
+			return &Debug{Writer: &debug}
+		}),
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"text/x-upper"}},
+				Body:       io.NopCloser(strings.NewReader("hello")),
+				Request:    req,
+			}, nil
+		})),
+	)
+	var reply string
+	if _, err := client.Invoke(context.Background(), http.MethodGet, "/", nil, &reply); err != nil || reply != "HELLO" {
+		t.Errorf("Invoke() failed: target=%s want=HELLO, %v", reply, err)
+	}
+	// Debug formats the body with the codecs of the client
+	if !strings.Contains(debug.String(), "HELLO") {
+		t.Errorf("Debug failed: %s", debug.String())
+	}
+}
+
+func TestCodecRegistryConcurrent(t *testing.T) {
+	registry := NewCodecRegistry()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			registry.RegisterByContentType("text/x-upper", upperCodec{})
+			RegisterCodecByContentType("application/json", encoding.GetCodec(json.Name))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		_ = registry.GetCodecByContentType("text/x-upper")
+		_ = GetCodecByContentType("application/json")
+	}
+	<-done
+}
+
+func TestTomlCodec(t *testing.T) {
+	type database struct {
+		Host  string   `toml:"host"`
+		Ports []int    `toml:"ports"`
+		Tags  []string `toml:"tags,omitempty"`
+	}
+	type config struct {
+		Title    string   `toml:"title"`
+		Database database `toml:"database"`
+	}
+
+	codec := GetCodecByContentType("application/toml")
+	data := "title = 'demo'\n\n[database]\nhost = 'localhost'\nports = [8000, 8001]\n"
+
+	var c config
+	if err := codec.Unmarshal([]byte(data), &c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Title != "demo" || c.Database.Host != "localhost" || len(c.Database.Ports) != 2 {
+		t.Errorf("toml Unmarshal() failed: %+v", c)
+	}
+
+	var m map[string]any
+	if err := codec.Unmarshal([]byte(data), &m); err != nil {
+		t.Fatal(err)
+	}
+	if db, ok := m["database"].(map[string]any); !ok || db["host"] != "localhost" {
+		t.Errorf("toml Unmarshal() map failed: %+v", m)
+	}
+
+	b, err := codec.Marshal(c)
+	if err != nil || string(b) != data {
+		t.Errorf("toml Marshal() failed: target=%q want=%q, %v", b, data, err)
+	}
+
+	result, err := formatIndent(codec, b)
+	want := "title = 'demo'\n\n[database]\n  host = 'localhost'\n  ports = [\n    8000,\n    8001\n  ]\n"
+	if err != nil || string(result) != want {
+		t.Errorf("formatIndent() failed: target=%q want=%q, %v", result, want, err)
+	}
+}
+
+func TestInvokeContentTyper(t *testing.T) {
+	tests := []struct {
+		args        any
+		contentType string
+		want        string
+	}{
+		{
+			args:        jsonpatch.New().Replace("/title", "new"),
+			contentType: jsonpatch.ContentType,
+			want:        `[{"op":"replace","path":"/title","value":"new"}]`,
+		},
+		{
+			args:        mergepatch.New().Delete("description"),
+			contentType: mergepatch.ContentType,
+			want:        `{"description":null}`,
+		},
+		{
+			args:        map[string]string{"title": "new"},
+			contentType: "application/json",
+			want:        `{"title":"new"}`,
+		},
 	}
 
-	for _, v := range tests {
-		target := GetCodecByContentType(v.contentType)
-		if target.Name() != v.want {
-			t.Logf("ContentSubtype() failed: target=%s want=%s", target.Name(), v.want)
+	for i, v := range tests {
+		client := NewClient(
+			WithEndpoint("http://example.com"),
+			WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				if ct := req.Header.Get("Content-Type"); ct != v.contentType {
+					t.Errorf("index: %d, Content-Type failed: target=%s want=%s", i, ct, v.contentType)
+				}
+				if b, _ := io.ReadAll(req.Body); string(b) != v.want {
+					t.Errorf("index: %d, body failed: target=%s want=%s", i, b, v.want)
+				}
+				return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: req}, nil
+			})),
+		)
+		if _, err := client.Invoke(context.Background(), http.MethodPatch, "/issues/1", v.args, nil); err != nil {
+			t.Errorf("index: %d, Invoke() failed: %s", i, err)
 		}
 	}
 }
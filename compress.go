@@ -0,0 +1,181 @@
+package ghttp
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content codings supported by request compression and response decompression.
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingBrotli  = "br"
+	EncodingZstd    = "zstd"
+)
+
+// acceptEncoding is sent if the request does not set Accept-Encoding.
+const acceptEncoding = "gzip, deflate, br, zstd"
+
+// WithRequestCompression compress the marshaled request body with encoding (gzip, deflate, br or zstd)
+// if it is at least threshold bytes, and set the Content-Encoding header.
+func WithRequestCompression(encoding string, threshold int) ClientOption {
+	return func(c *clientOptions) {
+		c.requestEncoding = strings.ToLower(encoding)
+		c.compressThreshold = threshold
+	}
+}
+
+func compress(encoding string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := newEncodingWriter(encoding, &buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(data); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func newEncodingWriter(encoding string, w io.Writer) (io.WriteCloser, error) {
+	switch encoding {
+	case EncodingGzip:
+		return gzip.NewWriter(w), nil
+	case EncodingDeflate:
+		// the deflate content coding is the zlib format (RFC 9110)
+		return zlib.NewWriter(w), nil
+	case EncodingBrotli:
+		return brotli.NewWriter(w), nil
+	case EncodingZstd:
+		return zstd.NewWriter(w)
+	}
+	return nil, fmt.Errorf("compress: unsupported content encoding: %s", encoding)
+}
+
+func newEncodingReader(encoding string, r io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case EncodingGzip:
+		return gzip.NewReader(r)
+	case EncodingDeflate:
+		return newDeflateReader(r)
+	case EncodingBrotli:
+		return io.NopCloser(brotli.NewReader(r)), nil
+	case EncodingZstd:
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+	return nil, fmt.Errorf("compress: unsupported content encoding: %s", encoding)
+}
+
+// newDeflateReader decodes the zlib format, or raw deflate which is sent by some servers by mistake.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	// CMF and FLG of zlib: deflate compression method and a multiple of 31 (RFC 1950)
+	if len(header) == 2 && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+func supportedEncoding(encoding string) bool {
+	switch encoding {
+	case EncodingGzip, EncodingDeflate, EncodingBrotli, EncodingZstd:
+		return true
+	}
+	return false
+}
+
+// decompressResponse decodes the response body like http.Transport does for gzip.
+func decompressResponse(response *http.Response) {
+	encoding := strings.ToLower(strings.TrimSpace(response.Header.Get("Content-Encoding")))
+	if response.Body == nil || response.Body == http.NoBody || !supportedEncoding(encoding) {
+		return
+	}
+	response.Body = &decompressBody{
+		body:     response.Body,
+		counter:  &countReader{r: response.Body},
+		encoding: encoding,
+	}
+	response.Header.Del("Content-Encoding")
+	response.Header.Del("Content-Length")
+	response.ContentLength = -1
+	response.Uncompressed = true
+}
+
+// decompressBody decodes the body lazily, so an empty body is not an error.
+type decompressBody struct {
+	body     io.ReadCloser
+	counter  *countReader
+	encoding string
+	reader   io.ReadCloser
+	err      error
+}
+
+func (d *decompressBody) Read(p []byte) (int, error) {
+	if d.reader == nil && d.err == nil {
+		d.reader, d.err = newEncodingReader(d.encoding, d.counter)
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.reader.Read(p)
+}
+
+func (d *decompressBody) Close() error {
+	if d.reader != nil {
+		_ = d.reader.Close()
+	}
+	return d.body.Close()
+}
+
+// compressedSize returns the number of compressed bytes read.
+func (d *decompressBody) compressedSize() (string, int64) {
+	return d.encoding, d.counter.n
+}
+
+// compressedBody returns the decompressing body under the wrappers of Client.Do.
+func compressedBody(body io.ReadCloser) (*decompressBody, bool) {
+	for body != nil {
+		if d, ok := body.(*decompressBody); ok {
+			return d, true
+		}
+		u, ok := body.(interface{ unwrap() io.ReadCloser })
+		if !ok {
+			break
+		}
+		body = u.unwrap()
+	}
+	return nil, false
+}
+
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
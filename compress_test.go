@@ -0,0 +1,102 @@
+package ghttp
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := io.Reader(r.Body)
+		if encoding := r.Header.Get("Content-Encoding"); encoding != "" {
+			rc, err := newEncodingReader(encoding, r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			defer rc.Close()
+			body = rc
+		}
+		reqBody, _ := io.ReadAll(body)
+
+		encoding := r.URL.Query().Get("encoding")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), encoding) {
+			http.Error(w, "Accept-Encoding: "+r.Header.Get("Accept-Encoding"), http.StatusBadRequest)
+			return
+		}
+		data, _ := compress(encoding, reqBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", encoding)
+		_, _ = w.Write(data)
+	}))
+	defer server.Close()
+
+	var debug bytes.Buffer
+	client := NewClient(
+		WithEndpoint(server.URL),
+		WithRequestCompression(EncodingZstd, 10),
+		WithDebug(func() DebugInterface {
+			return &Debug{Writer: &debug}
+		}),
+	)
+
+	args := map[string]string{"message": strings.Repeat("compress ", 10)}
+	for _, encoding := range []string{EncodingGzip, EncodingDeflate, EncodingBrotli, EncodingZstd} {
+		debug.Reset()
+		var reply map[string]string
+		_, err := client.Invoke(context.Background(), http.MethodPost, "/echo?encoding="+encoding, args, &reply)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reply["message"] != args["message"] {
+			t.Errorf("%s: reply failed: %v", encoding, reply)
+		}
+		for _, want := range []string{
+			"* request body compressed size:",
+			"(" + encoding + ")",
+			`"message": "compress`,
+		} {
+			if !strings.Contains(debug.String(), want) {
+				t.Errorf("%s: debug output does not contain %q:\n%s", encoding, want, debug.String())
+			}
+		}
+	}
+}
+
+func TestDeflateReader(t *testing.T) {
+	var zlibData, rawData bytes.Buffer
+	zw := zlib.NewWriter(&zlibData)
+	_, _ = zw.Write([]byte("deflate"))
+	_ = zw.Close()
+	fw, _ := flate.NewWriter(&rawData, flate.DefaultCompression)
+	_, _ = fw.Write([]byte("deflate"))
+	_ = fw.Close()
+
+	for i, data := range [][]byte{zlibData.Bytes(), rawData.Bytes()} {
+		r, err := newEncodingReader(EncodingDeflate, bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("index: %d, newEncodingReader() failed: %v", i, err)
+		}
+		b, err := io.ReadAll(r)
+		if err != nil || string(b) != "deflate" {
+			t.Errorf("index: %d, deflate failed: target=%s err=%v want=deflate", i, b, err)
+		}
+	}
+
+	// the encoded body is zlib
+	data, _ := compress(EncodingDeflate, []byte("deflate"))
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(zr); string(b) != "deflate" {
+		t.Errorf("compress() failed: target=%s want=deflate", b)
+	}
+}
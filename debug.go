@@ -17,6 +17,11 @@ import (
 	"time"
 
 	"github.com/zdz1715/ghttp/encoding"
+	"github.com/zdz1715/ghttp/encoding/binary"
+	"github.com/zdz1715/ghttp/encoding/cbor"
+	"github.com/zdz1715/ghttp/encoding/jsonpatch"
+	"github.com/zdz1715/ghttp/encoding/mergepatch"
+	"github.com/zdz1715/ghttp/encoding/toml"
 )
 
 type DebugInterface interface {
@@ -255,12 +260,24 @@ func (d *Debug) After(request *http.Request, response *http.Response) {
 	if request.GetBody != nil {
 		if reqBodyReader, err := request.GetBody(); err == nil {
 			reqBody, _ := io.ReadAll(reqBodyReader)
-			codec, _ := CodecForRequest(request)
+			// show the decompressed body
+			if encoding := request.Header.Get("Content-Encoding"); encoding != "" {
+				if r, err := newEncodingReader(encoding, bytes.NewReader(reqBody)); err == nil {
+					write(d.Writer, "* request body compressed size: %d bytes (%s)", len(reqBody), encoding)
+					reqBody, _ = io.ReadAll(r)
+					_ = r.Close()
+				}
+			}
+			codec, _ := codecsFromRequest(request).CodecForRequest(request)
 			reqBodyBs, _ := formatIndent(codec, reqBody)
 			if len(reqBodyBs) > 0 {
 				write(d.Writer, "")
 				write(d.Writer, "%s", string(reqBodyBs))
 				write(d.Writer, "")
+			} else if len(reqBody) > 0 {
+				write(d.Writer, "")
+				write(d.Writer, "%s", string(reqBody))
+				write(d.Writer, "")
 			}
 		}
 	} else {
@@ -272,12 +289,38 @@ func (d *Debug) After(request *http.Request, response *http.Response) {
 		write(d.Writer, "< %s: %s", k, strings.Join(v, ","))
 	}
 	write(d.Writer, "<")
-	// response body
-	if response.Body != nil {
+	if sniffed, ok := sniffedContentType(response); ok {
+		if sniffed == "" {
+			sniffed = "unknown format"
+		}
+		write(d.Writer, "* response body sniffed: %s", sniffed)
+	}
+	// response body, streaming body is consumed by the caller
+	if response.Body != nil && !isStreamResponse(response) {
 		//resBodyReader := io.Reader(response.Body)
 		if responseBody, err := io.ReadAll(response.Body); err == nil {
+			if body, ok := compressedBody(response.Body); ok {
+				encoding, size := body.compressedSize()
+				write(d.Writer, "* response body compressed size: %d bytes (%s)", size, encoding)
+			}
+			_ = response.Body.Close()
 			response.Body = io.NopCloser(bytes.NewBuffer(responseBody))
-			codec, _ := CodecForResponse(response)
+			codecs := codecsFromRequest(response.Request)
+			codec, _ := codecs.CodecForResponse(response)
+			if sniffed, ok := sniffedContentType(response); ok && sniffed != "" {
+				codec = codecs.GetCodecByContentType(sniffed)
+			}
+			// show the body in UTF-8
+			if codec != nil {
+				if r, err := decodeCharset(codec, &http.Response{
+					Header: response.Header,
+					Body:   io.NopCloser(bytes.NewReader(responseBody)),
+				}); err == nil {
+					if b, err := io.ReadAll(r); err == nil {
+						responseBody = b
+					}
+				}
+			}
 			resBodyBs, _ := formatIndent(codec, responseBody)
 			if len(resBodyBs) > 0 {
 				write(d.Writer, "")
@@ -298,13 +341,29 @@ func formatIndent(codec encoding.Codec, data []byte) (result []byte, err error)
 		return result, nil
 	}
 
+	// cbor is shown in diagnostic notation, it keeps the tags
+	if codec.Name() == cbor.Name {
+		diag, err := cbor.Diagnose(data)
+		return []byte(diag), err
+	}
+
+	if codec.Name() == toml.Name {
+		return toml.Indent(data)
+	}
+
+	// binary data is not printable
+	if codec.Name() == binary.Name {
+		return []byte(fmt.Sprintf("[%d bytes binary data]", len(data))), nil
+	}
+
 	var anyData any
 	if err = codec.Unmarshal(data, &anyData); err != nil {
 		return nil, err
 	}
 
 	switch codec.Name() {
-	case "json":
+	// binary formats are shown as json
+	case "json", "msgpack", jsonpatch.Name, mergepatch.Name:
 		result, err = json.MarshalIndent(anyData, "", "    ")
 	default:
 		result, err = codec.Marshal(anyData)
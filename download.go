@@ -0,0 +1,336 @@
+package ghttp
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	downloadTempSuffix = ".download"
+	downloadMetaSuffix = ".download.meta"
+)
+
+// DownloadOptions configure Client.Download.
+type DownloadOptions struct {
+	// Checksum is the expected digest of the file, formatted as "algorithm:hex",
+	// e.g. sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855.
+	// Supported algorithms are md5, sha1, sha256 and sha512.
+	Checksum string
+	// MaxRetries is the number of times an interrupted transfer (or each segment) is resumed in the same call.
+	MaxRetries int
+	// Segments is the number of byte ranges downloaded concurrently, it is enabled if > 1.
+	// A single stream is used if the server does not support ranges.
+	Segments int
+	// Progress is called after each write, total is -1 if unknown.
+	Progress func(downloaded, total int64)
+	// CallOptions are applied to every request.
+	CallOptions []CallOption
+}
+
+// ChecksumError is returned by Client.Download when the digest of the file does not match.
+type ChecksumError struct {
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("download: %s checksum mismatch: expected %s, got %s", e.Algorithm, e.Expected, e.Actual)
+}
+
+func parseChecksum(checksum string) (algorithm string, h hash.Hash, expected string, err error) {
+	if checksum == "" {
+		return "", nil, "", nil
+	}
+	algorithm, expected, ok := strings.Cut(checksum, ":")
+	if !ok {
+		return "", nil, "", fmt.Errorf("download: invalid checksum %q, want algorithm:hex", checksum)
+	}
+	algorithm = strings.ToLower(algorithm)
+	switch algorithm {
+	case "md5":
+		h = md5.New()
+	case "sha1":
+		h = sha1.New()
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return "", nil, "", fmt.Errorf("download: unsupported checksum algorithm: %s", algorithm)
+	}
+	return algorithm, h, strings.ToLower(expected), nil
+}
+
+// Download writes the response body of path to the file dst.
+//
+// The body is written to dst+".download" and renamed to dst when the transfer is complete and the
+// checksum is verified. An interrupted transfer is resumed by the next call (or by MaxRetries) with
+// the Range and If-Range headers, the validator (ETag or Last-Modified) is kept in dst+".download.meta".
+// The client timeout is not applied, use ctx to limit the transfer.
+//
+// With Segments > 1, the size of the file is checked by a HEAD request and the byte ranges are
+// downloaded concurrently into the pre-allocated temporary file, failed segments are retried on their own.
+func (c *Client) Download(ctx context.Context, path, dst string, opts *DownloadOptions) error {
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+	algorithm, h, expected, err := parseChecksum(opts.Checksum)
+	if err != nil {
+		return err
+	}
+
+	d := &download{
+		client: c,
+		path:   path,
+		dst:    dst,
+		opts:   opts,
+		hash:   h,
+	}
+
+	var segmented bool
+	if opts.Segments > 1 {
+		if segmented, err = d.fetchSegments(ctx); err != nil {
+			return err
+		}
+	}
+
+	for retries := 0; !segmented; retries++ {
+		err = d.fetch(ctx)
+		if err == nil {
+			break
+		}
+		if !isResumable(err) || retries >= opts.MaxRetries || ctx.Err() != nil {
+			return err
+		}
+	}
+
+	if h != nil {
+		if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+			_ = os.Remove(dst + downloadTempSuffix)
+			_ = os.Remove(dst + downloadMetaSuffix)
+			return &ChecksumError{Algorithm: algorithm, Expected: expected, Actual: actual}
+		}
+	}
+
+	if err = os.Rename(dst+downloadTempSuffix, dst); err != nil {
+		return err
+	}
+	_ = os.Remove(dst + downloadMetaSuffix)
+	return nil
+}
+
+// downloadError is an interrupted transfer, the temporary file is kept.
+type downloadError struct {
+	err       error
+	resumable bool
+}
+
+func (e *downloadError) Error() string {
+	return e.err.Error()
+}
+
+func (e *downloadError) Unwrap() error {
+	return e.err
+}
+
+func isResumable(err error) bool {
+	var dErr *downloadError
+	return errors.As(err, &dErr) && dErr.resumable
+}
+
+type download struct {
+	client *Client
+	path   string
+	dst    string
+	opts   *DownloadOptions
+	hash   hash.Hash
+}
+
+// offset returns the size of the temporary file and the validator to resume it.
+func (d *download) offset() (int64, string) {
+	info, err := os.Stat(d.dst + downloadTempSuffix)
+	if err != nil || info.Size() == 0 {
+		return 0, ""
+	}
+	validator, err := os.ReadFile(d.dst + downloadMetaSuffix)
+	if err != nil || len(validator) == 0 {
+		return 0, ""
+	}
+	return info.Size(), string(validator)
+}
+
+func (d *download) fetch(ctx context.Context) error {
+	offset, validator := d.offset()
+
+	req, err := d.client.newRequest(streamingContext(ctx), http.MethodGet, d.path, nil)
+	if err != nil {
+		return err
+	}
+	// the body is written as is
+	req.Header.Set("Accept-Encoding", "identity")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
+	}
+
+	response, err := d.client.Do(req, d.opts.CallOptions...)
+	if err != nil {
+		return &downloadError{err: err, resumable: !IsHTTPNot2xxError(err)}
+	}
+	defer response.Body.Close()
+
+	total := response.ContentLength
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+
+	switch {
+	case response.StatusCode == http.StatusPartialContent && offset > 0:
+		start, size, err := parseContentRange(response.Header.Get("Content-Range"))
+		if err != nil {
+			return err
+		}
+		if start != offset {
+			return fmt.Errorf("download: unexpected Content-Range: %s", response.Header.Get("Content-Range"))
+		}
+		total = size
+		flag = os.O_WRONLY | os.O_APPEND
+	case response.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// the temporary file is complete
+		if _, size, err := parseContentRange(response.Header.Get("Content-Range")); err == nil && size == offset {
+			return d.hashFile(offset)
+		}
+		// start over
+		_ = response.Body.Close()
+		_ = os.Remove(d.dst + downloadTempSuffix)
+		_ = os.Remove(d.dst + downloadMetaSuffix)
+		return d.fetch(ctx)
+	case Not2xxCode(response.StatusCode) || response.StatusCode == http.StatusPartialContent:
+		return &HTTPNot2xxError{
+			URL:        response.Request.URL,
+			Method:     response.Request.Method,
+			StatusCode: response.StatusCode,
+		}
+	default:
+		offset = 0
+	}
+
+	// keep the validator to resume
+	if v := responseValidator(response); v != "" {
+		if err = os.WriteFile(d.dst+downloadMetaSuffix, []byte(v), 0o644); err != nil {
+			return err
+		}
+	} else {
+		_ = os.Remove(d.dst + downloadMetaSuffix)
+	}
+
+	f, err := os.OpenFile(d.dst+downloadTempSuffix, flag, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if err = d.hashFile(offset); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if d.opts.Progress != nil && offset > 0 {
+		d.opts.Progress(offset, total)
+	}
+
+	var w io.Writer = f
+	if d.hash != nil {
+		w = io.MultiWriter(f, d.hash)
+	}
+	if d.opts.Progress != nil {
+		w = &progressWriter{w: w, written: offset, total: total, fn: d.opts.Progress}
+	}
+
+	_, err = io.Copy(w, response.Body)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return &downloadError{err: err, resumable: true}
+	}
+	return nil
+}
+
+// hashFile resets the hash with the first offset bytes of the temporary file.
+func (d *download) hashFile(offset int64) error {
+	if d.hash == nil {
+		return nil
+	}
+	d.hash.Reset()
+	if offset == 0 {
+		return nil
+	}
+	f, err := os.Open(d.dst + downloadTempSuffix)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.CopyN(d.hash, f, offset)
+	return err
+}
+
+// responseValidator returns the strong ETag or Last-Modified used by If-Range.
+func responseValidator(response *http.Response) string {
+	if etag := response.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return response.Header.Get("Last-Modified")
+}
+
+// parseContentRange parses "bytes 100-199/200" or "bytes */200", size is -1 if unknown.
+func parseContentRange(contentRange string) (start, size int64, err error) {
+	spec, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return 0, 0, fmt.Errorf("download: invalid Content-Range: %s", contentRange)
+	}
+	rng, sizeStr, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("download: invalid Content-Range: %s", contentRange)
+	}
+	size = -1
+	if sizeStr != "*" {
+		if size, err = strconv.ParseInt(sizeStr, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("download: invalid Content-Range: %s", contentRange)
+		}
+	}
+	if rng == "*" {
+		return 0, size, nil
+	}
+	startStr, _, _ := strings.Cut(rng, "-")
+	if start, err = strconv.ParseInt(startStr, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("download: invalid Content-Range: %s", contentRange)
+	}
+	return start, size, nil
+}
+
+type progressWriter struct {
+	w       io.Writer
+	written int64
+	total   int64
+	fn      func(written, total int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	p.fn(p.written, p.total)
+	return n, err
+}
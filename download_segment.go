@@ -0,0 +1,176 @@
+package ghttp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// fetchSegments downloads the byte ranges of the file concurrently,
+// ok is false if the server does not support ranges.
+func (d *download) fetchSegments(ctx context.Context) (ok bool, err error) {
+	size, validator, ok := d.probe(ctx)
+	if !ok {
+		return false, nil
+	}
+
+	// segments are not resumed by the next call
+	_ = os.Remove(d.dst + downloadMetaSuffix)
+
+	f, err := os.OpenFile(d.dst+downloadTempSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return true, err
+	}
+	defer f.Close()
+
+	if err = f.Truncate(size); err != nil {
+		return true, err
+	}
+
+	segments := int64(d.opts.Segments)
+	if segments > size {
+		segments = size
+	}
+	segmentSize := size / segments
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		progress = &segmentProgress{total: size, fn: d.opts.Progress}
+	)
+	for i := int64(0); i < segments; i++ {
+		start := i * segmentSize
+		end := start + segmentSize - 1
+		if i == segments-1 {
+			end = size - 1
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.fetchSegment(ctx, f, start, end, validator, progress); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return true, firstErr
+	}
+	if err = f.Sync(); err != nil {
+		return true, err
+	}
+	return true, d.hashFile(size)
+}
+
+// probe checks the size of the file and whether the server supports ranges by a HEAD request.
+func (d *download) probe(ctx context.Context) (size int64, validator string, ok bool) {
+	req, err := d.client.newRequest(ctx, http.MethodHead, d.path, nil)
+	if err != nil {
+		return 0, "", false
+	}
+	req.Header.Set("Accept-Encoding", "identity")
+
+	response, err := d.client.Do(req, d.opts.CallOptions...)
+	if err != nil {
+		return 0, "", false
+	}
+	_ = response.Body.Close()
+
+	if response.StatusCode != http.StatusOK || response.ContentLength <= 0 ||
+		!strings.EqualFold(response.Header.Get("Accept-Ranges"), "bytes") {
+		return 0, "", false
+	}
+	return response.ContentLength, responseValidator(response), true
+}
+
+// fetchSegment downloads the byte range [start, end], it is resumed from the written offset on error.
+func (d *download) fetchSegment(ctx context.Context, f *os.File, start, end int64, validator string, progress *segmentProgress) error {
+	for retries := 0; ; retries++ {
+		n, err := d.fetchRange(ctx, f, start, end, validator, progress)
+		start += n
+		if err == nil {
+			return nil
+		}
+		if !isResumable(err) || retries >= d.opts.MaxRetries || ctx.Err() != nil {
+			return err
+		}
+	}
+}
+
+func (d *download) fetchRange(ctx context.Context, f *os.File, start, end int64, validator string, progress *segmentProgress) (int64, error) {
+	req, err := d.client.newRequest(streamingContext(ctx), http.MethodGet, d.path, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept-Encoding", "identity")
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	if validator != "" {
+		req.Header.Set("If-Range", validator)
+	}
+
+	response, err := d.client.Do(req, d.opts.CallOptions...)
+	if err != nil {
+		return 0, &downloadError{err: err, resumable: !IsHTTPNot2xxError(err)}
+	}
+	defer response.Body.Close()
+
+	// 200 means the file has changed since the HEAD request
+	if response.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("download: segment %d-%d: unexpected status: %s", start, end, response.Status)
+	}
+	if rangeStart, _, err := parseContentRange(response.Header.Get("Content-Range")); err != nil || rangeStart != start {
+		return 0, fmt.Errorf("download: segment %d-%d: unexpected Content-Range: %s", start, end, response.Header.Get("Content-Range"))
+	}
+
+	length := end - start + 1
+	w := &segmentWriter{w: io.NewOffsetWriter(f, start), progress: progress}
+	n, err := io.Copy(w, io.LimitReader(response.Body, length))
+	if err == nil && n < length {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return n, &downloadError{err: err, resumable: true}
+	}
+	return n, nil
+}
+
+// segmentProgress reports the progress of all segments.
+type segmentProgress struct {
+	mu      sync.Mutex
+	written int64
+	total   int64
+	fn      func(written, total int64)
+}
+
+func (p *segmentProgress) add(n int64) {
+	if p.fn == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.written += n
+	p.fn(p.written, p.total)
+}
+
+type segmentWriter struct {
+	w        io.Writer
+	progress *segmentProgress
+}
+
+func (s *segmentWriter) Write(b []byte) (int, error) {
+	n, err := s.w.Write(b)
+	s.progress.add(int64(n))
+	return n, err
+}
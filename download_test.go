@@ -0,0 +1,146 @@
+package ghttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	sum := sha256.Sum256(content)
+	checksum := "sha256:" + hex.EncodeToString(sum[:])
+
+	var requests int32
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", `"v1"`)
+		// the first transfer is interrupted
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Content-Length", "100000")
+			_, _ = w.Write(content[:30000])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithEndpoint(server.URL))
+	dst := filepath.Join(t.TempDir(), "file.bin")
+
+	var downloaded, total int64
+	err := client.Download(context.Background(), "/file.bin", dst, &DownloadOptions{
+		Checksum:   checksum,
+		MaxRetries: 1,
+		Progress: func(n, t int64) {
+			downloaded, total = n, t
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := os.ReadFile(dst)
+	if !bytes.Equal(b, content) {
+		t.Errorf("Download() failed: size=%d want=%d", len(b), len(content))
+	}
+	if strings.Join(ranges, ",") != ",bytes=30000-" {
+		t.Errorf("Download() Range failed: target=%q", ranges)
+	}
+	if downloaded != 100000 || total != 100000 {
+		t.Errorf("Download() Progress failed: downloaded=%d total=%d", downloaded, total)
+	}
+	if _, err = os.Stat(dst + downloadTempSuffix); !os.IsNotExist(err) {
+		t.Errorf("Download() temporary file is not removed: %v", err)
+	}
+
+	// checksum mismatch
+	err = client.Download(context.Background(), "/file.bin", dst+".2", &DownloadOptions{
+		Checksum: "sha256:" + strings.Repeat("0", 64),
+	})
+	var checksumErr *ChecksumError
+	if !errors.As(err, &checksumErr) {
+		t.Errorf("Download() checksum failed: %v", err)
+	}
+	if _, err = os.Stat(dst + ".2"); !os.IsNotExist(err) {
+		t.Errorf("Download() file with mismatched checksum is renamed: %v", err)
+	}
+}
+
+func TestDownloadSegments(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+	sum := sha256.Sum256(content)
+	checksum := "sha256:" + hex.EncodeToString(sum[:])
+
+	var failed, requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/ranges":
+			// the second segment fails once
+			if r.Header.Get("Range") == "bytes=25000-49999" && atomic.AddInt32(&failed, 1) == 1 {
+				w.Header().Set("Content-Range", "bytes 25000-49999/100000")
+				w.Header().Set("Content-Length", "25000")
+				w.WriteHeader(http.StatusPartialContent)
+				_, _ = w.Write(content[25000:30000])
+				w.(http.Flusher).Flush()
+				panic(http.ErrAbortHandler)
+			}
+			http.ServeContent(w, r, "file.bin", time.Time{}, bytes.NewReader(content))
+		default:
+			_, _ = w.Write(content)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(WithEndpoint(server.URL))
+
+	tests := []struct {
+		path     string
+		requests int32
+	}{
+		// HEAD + 4 segments + 1 retry
+		{path: "/ranges", requests: 6},
+		// HEAD + single stream
+		{path: "/no-ranges", requests: 2},
+	}
+
+	for i, v := range tests {
+		atomic.StoreInt32(&requests, 0)
+		dst := filepath.Join(t.TempDir(), "file.bin")
+		var downloaded int64
+		err := client.Download(context.Background(), v.path, dst, &DownloadOptions{
+			Checksum:   checksum,
+			Segments:   4,
+			MaxRetries: 1,
+			Progress: func(n, total int64) {
+				downloaded = n
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := os.ReadFile(dst)
+		if !bytes.Equal(b, content) {
+			t.Errorf("index: %d, Download() failed: size=%d want=%d", i, len(b), len(content))
+		}
+		if n := atomic.LoadInt32(&requests); n != v.requests {
+			t.Errorf("index: %d, Download() requests failed: target=%d want=%d", i, n, v.requests)
+		}
+		if downloaded != int64(len(content)) {
+			t.Errorf("index: %d, Download() Progress failed: target=%d want=%d", i, downloaded, len(content))
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package binary
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+
+	gencoding "github.com/zdz1715/ghttp/encoding"
+)
+
+// Name is the name registered for the binary codec.
+const Name = "binary"
+
+func init() {
+	gencoding.RegisterCodec(codec{})
+}
+
+// codec is a Codec implementation with raw bytes, the body is not transformed.
+type codec struct{}
+
+// Marshal supports []byte, string, encoding.BinaryMarshaler and encoding.TextMarshaler.
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return t, nil
+	case *[]byte:
+		if t == nil {
+			return nil, nil
+		}
+		return *t, nil
+	case string:
+		return []byte(t), nil
+	case *string:
+		if t == nil {
+			return nil, nil
+		}
+		return []byte(*t), nil
+	case encoding.BinaryMarshaler:
+		return t.MarshalBinary()
+	case encoding.TextMarshaler:
+		return t.MarshalText()
+	}
+	return nil, fmt.Errorf("binary: Marshal() unsupported type %T", v)
+}
+
+// Unmarshal supports *[]byte, *string, *any ([]byte), io.Writer,
+// encoding.BinaryUnmarshaler and encoding.TextUnmarshaler.
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	switch t := v.(type) {
+	case *[]byte:
+		*t = append((*t)[:0], data...)
+	case *string:
+		*t = string(data)
+	case *interface{}:
+		*t = append([]byte(nil), data...)
+	case encoding.BinaryUnmarshaler:
+		return t.UnmarshalBinary(data)
+	case encoding.TextUnmarshaler:
+		return t.UnmarshalText(data)
+	case io.Writer:
+		_, err := t.Write(data)
+		return err
+	default:
+		return fmt.Errorf("binary: Unmarshal() unsupported type %T", v)
+	}
+	return nil
+}
+
+func (c codec) NewEncoder(w io.Writer) gencoding.Encoder {
+	return gencoding.NewRawEncoder(w, c.Marshal)
+}
+
+func (c codec) NewDecoder(r io.Reader) gencoding.Decoder {
+	return gencoding.NewRawDecoder(r, c.Unmarshal)
+}
+
+func (codec) Name() string {
+	return Name
+}
@@ -0,0 +1,78 @@
+package cbor
+
+import (
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+
+	"github.com/zdz1715/ghttp/encoding"
+)
+
+// Name is the name registered for the cbor codec.
+const Name = "cbor"
+
+var (
+	// EncMode encodes with the RFC 8949 Core Deterministic Encoding, so the output can be signed,
+	// time.Time is encoded as an RFC 3339 string with tag 0.
+	EncMode = mustEncMode(cbor.EncOptions{
+		Sort:          cbor.SortCoreDeterministic,
+		ShortestFloat: cbor.ShortestFloat16,
+		NaNConvert:    cbor.NaNConvert7e00,
+		InfConvert:    cbor.InfConvertFloat16,
+		IndefLength:   cbor.IndefLengthForbidden,
+		Time:          cbor.TimeRFC3339Nano,
+		TimeTag:       cbor.EncTagRequired,
+	})
+	// DecMode decodes tag 0 (RFC 3339 string) and tag 1 (epoch) into time.Time.
+	DecMode = mustDecMode(cbor.DecOptions{
+		TimeTag: cbor.DecTagOptional,
+	})
+)
+
+func mustEncMode(opts cbor.EncOptions) cbor.EncMode {
+	em, err := opts.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return em
+}
+
+func mustDecMode(opts cbor.DecOptions) cbor.DecMode {
+	dm, err := opts.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return dm
+}
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec is a Codec implementation with cbor, struct fields use the cbor tag, or the json tag if it is not set.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return EncMode.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return DecMode.Unmarshal(data, v)
+}
+
+func (codec) NewEncoder(w io.Writer) encoding.Encoder {
+	return EncMode.NewEncoder(w)
+}
+
+func (codec) NewDecoder(r io.Reader) encoding.Decoder {
+	return DecMode.NewDecoder(r)
+}
+
+func (codec) Name() string {
+	return Name
+}
+
+// Diagnose returns the Extended Diagnostic Notation (RFC 8949 Section 8) of data, it is human-readable.
+func Diagnose(data []byte) (string, error) {
+	return cbor.Diagnose(data)
+}
@@ -0,0 +1,314 @@
+package csv
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	gencoding "github.com/zdz1715/ghttp/encoding"
+)
+
+// Name is the name registered for the csv codec.
+const Name = "csv"
+
+// Tag is the struct tag of the column name, "-" ignores the field.
+const Tag = "csv"
+
+func init() {
+	gencoding.RegisterCodec(NewCodec(Options{}))
+}
+
+// Options configure the csv codec.
+type Options struct {
+	// Comma is the field delimiter, default ','.
+	Comma rune
+	// NoHeader reports the first row is a record, the columns are mapped to the fields in order.
+	NoHeader bool
+	// TimeFormat is the layout of time.Time fields, default time.RFC3339.
+	TimeFormat string
+}
+
+// NewCodec returns a csv codec, it binds rows to []T (or []*T) using the csv struct tag.
+// It can be registered to replace the default codec:
+//
+//	ghttp.RegisterCodecByContentType("text/csv", csv.NewCodec(csv.Options{Comma: ';'}))
+func NewCodec(opts Options) gencoding.Codec {
+	if opts.Comma == 0 {
+		opts.Comma = ','
+	}
+	if opts.TimeFormat == "" {
+		opts.TimeFormat = time.RFC3339
+	}
+	return codec{opts: opts}
+}
+
+// codec is a Codec implementation with csv.
+type codec struct {
+	opts Options
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type column struct {
+	name  string
+	index []int
+}
+
+func structColumns(typ reflect.Type) []column {
+	var columns []column
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get(Tag), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		columns = append(columns, column{name: name, index: sf.Index})
+	}
+	return columns
+}
+
+func (c codec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = c.opts.Comma
+
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	if records, ok := rv.Interface().([][]string); ok {
+		if err := w.WriteAll(records); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("csv: Marshal() unsupported type %T, want []struct", v)
+	}
+	elemType := rv.Type().Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("csv: Marshal() unsupported type %T, want []struct", v)
+	}
+
+	columns := structColumns(elemType)
+	if !c.opts.NoHeader {
+		header := make([]string, len(columns))
+		for i, col := range columns {
+			header[i] = col.name
+		}
+		if err := w.Write(header); err != nil {
+			return nil, err
+		}
+	}
+
+	record := make([]string, len(columns))
+	for i := 0; i < rv.Len(); i++ {
+		elem := rv.Index(i)
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if !elem.IsValid() {
+			continue
+		}
+		for j, col := range columns {
+			s, err := c.formatValue(elem.FieldByIndex(col.index))
+			if err != nil {
+				return nil, fmt.Errorf("csv: column %q: %w", col.name, err)
+			}
+			record[j] = s
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func (c codec) Unmarshal(data []byte, v interface{}) error {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = c.opts.Comma
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("csv: Unmarshal() non-pointer %T", v)
+	}
+	rv = rv.Elem()
+
+	// any and [][]string get all rows
+	if rv.Kind() == reflect.Interface && rv.NumMethod() == 0 {
+		rv.Set(reflect.ValueOf(records))
+		return nil
+	}
+	if rv.Type() == reflect.TypeOf(records) {
+		rv.Set(reflect.ValueOf(records))
+		return nil
+	}
+
+	if rv.Kind() != reflect.Slice {
+		return fmt.Errorf("csv: Unmarshal() unsupported type %T, want *[]struct", v)
+	}
+	elemType := rv.Type().Elem()
+	structType := elemType
+	for structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("csv: Unmarshal() unsupported type %T, want *[]struct", v)
+	}
+
+	columns := structColumns(structType)
+	// mapping of record index to column
+	mapping := make([]*column, 0, len(columns))
+	if c.opts.NoHeader {
+		for i := range columns {
+			mapping = append(mapping, &columns[i])
+		}
+	} else if len(records) > 0 {
+		byName := make(map[string]*column, len(columns))
+		for i := range columns {
+			byName[columns[i].name] = &columns[i]
+		}
+		for _, name := range records[0] {
+			name = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+			mapping = append(mapping, byName[name])
+		}
+		records = records[1:]
+	}
+
+	slice := reflect.MakeSlice(rv.Type(), len(records), len(records))
+	for i, record := range records {
+		elem := slice.Index(i)
+		for elem.Kind() == reflect.Ptr {
+			elem.Set(reflect.New(elem.Type().Elem()))
+			elem = elem.Elem()
+		}
+		for j, s := range record {
+			if j >= len(mapping) || mapping[j] == nil {
+				continue
+			}
+			if err = c.parseValue(elem.FieldByIndex(mapping[j].index), s); err != nil {
+				return fmt.Errorf("csv: row %d column %q: %w", i+1, mapping[j].name, err)
+			}
+		}
+	}
+	rv.Set(slice)
+	return nil
+}
+
+func (c codec) formatValue(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return "", nil
+		}
+		return t.Format(c.opts.TimeFormat), nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	return fmt.Sprint(v.Interface()), nil
+}
+
+func (c codec) parseValue(v reflect.Value, s string) error {
+	if s == "" {
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return c.parseValue(v.Elem(), s)
+	}
+	if v.Type() == timeType {
+		t, err := time.Parse(c.opts.TimeFormat, s)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(s))
+	default:
+		return fmt.Errorf("unsupported kind %v", v.Kind())
+	}
+	return nil
+}
+
+func (codec) Name() string {
+	return Name
+}
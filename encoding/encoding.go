@@ -1,6 +1,10 @@
 package encoding
 
-import "strings"
+import (
+	"io"
+	"strings"
+	"sync"
+)
 
 // Codec defines the interface Transport uses to encode and decode messages.  Note
 // that implementations of this interface must be thread safe; a Codec's
@@ -16,8 +20,44 @@ type Codec interface {
 	Name() string
 }
 
-var registeredCodecs = make(map[string]Codec)
+// Encoder writes the wire format of a value to a stream.
+type Encoder interface {
+	Encode(v interface{}) error
+}
+
+// Decoder reads the wire format of a value from a stream.
+type Decoder interface {
+	Decode(v interface{}) error
+}
+
+// StreamCodec is optionally implemented by a Codec to encode to an io.Writer and decode from an io.Reader
+// without buffering the whole message. The client encodes or decodes one value per stream.
+type StreamCodec interface {
+	Codec
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+// EncoderFunc is an adapter to allow the use of ordinary functions as Encoder.
+type EncoderFunc func(v interface{}) error
+
+func (f EncoderFunc) Encode(v interface{}) error {
+	return f(v)
+}
+
+// DecoderFunc is an adapter to allow the use of ordinary functions as Decoder.
+type DecoderFunc func(v interface{}) error
+
+func (f DecoderFunc) Decode(v interface{}) error {
+	return f(v)
+}
+
+var (
+	registeredCodecs = make(map[string]Codec)
+	mu               sync.RWMutex
+)
 
+// RegisterCodec registers codec globally, it is safe for concurrent use.
 func RegisterCodec(codec Codec) {
 	if codec == nil {
 		panic("cannot register a nil Codec")
@@ -26,9 +66,13 @@ func RegisterCodec(codec Codec) {
 		panic("cannot register Codec with empty string result for Name()")
 	}
 	contentSubtype := strings.ToLower(codec.Name())
+	mu.Lock()
+	defer mu.Unlock()
 	registeredCodecs[contentSubtype] = codec
 }
 
 func GetCodec(name string) Codec {
+	mu.RLock()
+	defer mu.RUnlock()
 	return registeredCodecs[name]
 }
@@ -1,7 +1,10 @@
 package json
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"io"
 	"reflect"
 
 	"google.golang.org/protobuf/proto"
@@ -24,30 +27,90 @@ var (
 	}
 )
 
+// Options configure a json codec created by NewCodec.
+type Options struct {
+	// UseNumber decodes a number into an interface{} as a json.Number instead of a float64,
+	// so large integers (e.g. IDs) keep their precision.
+	UseNumber bool
+	// DisallowUnknownFields returns an error if the object has a key which does not match
+	// any exported field of the destination struct.
+	DisallowUnknownFields bool
+	// MarshalOptions marshal proto.Message.
+	MarshalOptions protojson.MarshalOptions
+	// UnmarshalOptions unmarshal proto.Message.
+	UnmarshalOptions protojson.UnmarshalOptions
+}
+
+// DefaultOptions returns the options of the registered codec.
+func DefaultOptions() Options {
+	return Options{
+		MarshalOptions:   MarshalOptions,
+		UnmarshalOptions: UnmarshalOptions,
+	}
+}
+
+// NewCodec returns a json codec with opts, it does not change the registered codec:
+//
+//	opts := json.DefaultOptions()
+//	opts.UseNumber = true
+//	ghttp.NewClient(ghttp.WithCodec(json.NewCodec(opts)))
+func NewCodec(opts Options) encoding.Codec {
+	return codec{opts: &opts}
+}
+
 func init() {
 	encoding.RegisterCodec(codec{})
 }
 
-// codec is a Codec implementation with json.
-type codec struct{}
+// codec is a Codec implementation with json, the package options are used if opts is nil.
+type codec struct {
+	opts *Options
+}
+
+func (c codec) marshalOptions() protojson.MarshalOptions {
+	if c.opts == nil {
+		return MarshalOptions
+	}
+	return c.opts.MarshalOptions
+}
+
+func (c codec) unmarshalOptions() protojson.UnmarshalOptions {
+	if c.opts == nil {
+		return UnmarshalOptions
+	}
+	return c.opts.UnmarshalOptions
+}
+
+func (c codec) newDecoder(r io.Reader) *json.Decoder {
+	dec := json.NewDecoder(r)
+	if c.opts != nil {
+		if c.opts.UseNumber {
+			dec.UseNumber()
+		}
+		if c.opts.DisallowUnknownFields {
+			dec.DisallowUnknownFields()
+		}
+	}
+	return dec
+}
 
-func (codec) Marshal(v interface{}) ([]byte, error) {
+func (c codec) Marshal(v interface{}) ([]byte, error) {
 	switch m := v.(type) {
 	case json.Marshaler:
 		return m.MarshalJSON()
 	case proto.Message:
-		return MarshalOptions.Marshal(m)
+		return c.marshalOptions().Marshal(m)
 	default:
 		return json.Marshal(m)
 	}
 }
 
-func (codec) Unmarshal(data []byte, v interface{}) error {
+func (c codec) Unmarshal(data []byte, v interface{}) error {
 	switch m := v.(type) {
 	case json.Unmarshaler:
 		return m.UnmarshalJSON(data)
 	case proto.Message:
-		return UnmarshalOptions.Unmarshal(data, m)
+		return c.unmarshalOptions().Unmarshal(data, m)
 	default:
 		rv := reflect.ValueOf(v)
 		for rv := rv; rv.Kind() == reflect.Ptr; {
@@ -57,12 +120,69 @@ func (codec) Unmarshal(data []byte, v interface{}) error {
 			rv = rv.Elem()
 		}
 		if m, ok := reflect.Indirect(rv).Interface().(proto.Message); ok {
-			return UnmarshalOptions.Unmarshal(data, m)
+			return c.unmarshalOptions().Unmarshal(data, m)
 		}
-		return json.Unmarshal(data, m)
+		if c.opts == nil {
+			return json.Unmarshal(data, m)
+		}
+		return decodeOne(c.newDecoder(bytes.NewReader(data)), m)
 	}
 }
 
 func (codec) Name() string {
 	return Name
 }
+
+func (c codec) NewEncoder(w io.Writer) encoding.Encoder {
+	return encoding.EncoderFunc(func(v interface{}) error {
+		if m, ok := v.(proto.Message); ok {
+			data, err := c.marshalOptions().Marshal(m)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		}
+		return json.NewEncoder(w).Encode(v)
+	})
+}
+
+func (c codec) NewDecoder(r io.Reader) encoding.Decoder {
+	return encoding.DecoderFunc(func(v interface{}) error {
+		// protojson does not support streams
+		if isProtoMessage(v) {
+			data, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			return c.Unmarshal(data, v)
+		}
+		return decodeOne(c.newDecoder(r), v)
+	})
+}
+
+// decodeOne decodes the only value of the stream, trailing data is an error like json.Unmarshal.
+func decodeOne(dec *json.Decoder, v interface{}) error {
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("json: invalid character after top-level value")
+	}
+	return nil
+}
+
+var protoMessageType = reflect.TypeOf((*proto.Message)(nil)).Elem()
+
+// isProtoMessage reports whether v is a proto.Message or a pointer to it.
+func isProtoMessage(v interface{}) bool {
+	for t := reflect.TypeOf(v); t != nil; t = t.Elem() {
+		if t.Implements(protoMessageType) {
+			return true
+		}
+		if t.Kind() != reflect.Ptr {
+			break
+		}
+	}
+	return false
+}
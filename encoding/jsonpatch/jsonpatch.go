@@ -0,0 +1,200 @@
+// Package jsonpatch builds JSON Patch (RFC 6902) documents, sent as application/json-patch+json.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/zdz1715/ghttp/encoding"
+)
+
+// Name is the name registered for the json-patch codec.
+const Name = "json-patch"
+
+// ContentType is the media type of a JSON Patch document.
+const ContentType = "application/json-patch+json"
+
+// Operations defined by RFC 6902.
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec is a Codec implementation with json for JSON Patch documents.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (codec) Name() string {
+	return Name
+}
+
+// Operation is an operation of a JSON Patch, Path and From are JSON Pointers (RFC 6901).
+type Operation struct {
+	Op    string
+	Path  string
+	From  string
+	Value any
+}
+
+type operation struct {
+	Op    string           `json:"op"`
+	Path  string           `json:"path"`
+	From  string           `json:"from,omitempty"`
+	Value *json.RawMessage `json:"value,omitempty"`
+}
+
+// MarshalJSON keeps the value of add, replace and test even if it is null.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	op := operation{Op: o.Op, Path: o.Path, From: o.From}
+	switch o.Op {
+	case OpAdd, OpReplace, OpTest:
+		value, err := json.Marshal(o.Value)
+		if err != nil {
+			return nil, err
+		}
+		raw := json.RawMessage(value)
+		op.Value = &raw
+	}
+	return json.Marshal(op)
+}
+
+func (o *Operation) UnmarshalJSON(data []byte) error {
+	var op operation
+	if err := json.Unmarshal(data, &op); err != nil {
+		return err
+	}
+	*o = Operation{Op: op.Op, Path: op.Path, From: op.From}
+	if op.Value != nil {
+		return json.Unmarshal(*op.Value, &o.Value)
+	}
+	return nil
+}
+
+// Patch is a JSON Patch document, pass it as args of Client.Invoke to send it with ContentType.
+//
+//	patch := jsonpatch.New().Replace("/title", "new title").Remove("/labels/0")
+type Patch []Operation
+
+func New() Patch {
+	return Patch{}
+}
+
+// ContentType implements ghttp.ContentTyper.
+func (p Patch) ContentType() string {
+	return ContentType
+}
+
+func (p Patch) Add(path string, value any) Patch {
+	return append(p, Operation{Op: OpAdd, Path: path, Value: value})
+}
+
+func (p Patch) Remove(path string) Patch {
+	return append(p, Operation{Op: OpRemove, Path: path})
+}
+
+func (p Patch) Replace(path string, value any) Patch {
+	return append(p, Operation{Op: OpReplace, Path: path, Value: value})
+}
+
+func (p Patch) Move(from, path string) Patch {
+	return append(p, Operation{Op: OpMove, From: from, Path: path})
+}
+
+func (p Patch) Copy(from, path string) Patch {
+	return append(p, Operation{Op: OpCopy, From: from, Path: path})
+}
+
+func (p Patch) Test(path string, value any) Patch {
+	return append(p, Operation{Op: OpTest, Path: path, Value: value})
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// Pointer returns the JSON Pointer of the reference tokens, e.g. Pointer("a/b", "0") is /a~1b/0.
+func Pointer(tokens ...string) string {
+	var buf strings.Builder
+	for _, token := range tokens {
+		buf.WriteByte('/')
+		buf.WriteString(pointerEscaper.Replace(token))
+	}
+	return buf.String()
+}
+
+// Diff returns the patch that transforms the JSON of original into the JSON of modified.
+// Objects are compared member by member, arrays that are not equal are replaced.
+func Diff(original, modified any) (Patch, error) {
+	a, err := toJSONValue(original)
+	if err != nil {
+		return nil, err
+	}
+	b, err := toJSONValue(modified)
+	if err != nil {
+		return nil, err
+	}
+	return diff(New(), "", a, b), nil
+}
+
+func diff(patch Patch, path string, a, b any) Patch {
+	objA, okA := a.(map[string]any)
+	objB, okB := b.(map[string]any)
+	if !okA || !okB {
+		if !reflect.DeepEqual(a, b) {
+			patch = patch.Replace(path, b)
+		}
+		return patch
+	}
+
+	keys := make([]string, 0, len(objA)+len(objB))
+	for k := range objA {
+		keys = append(keys, k)
+	}
+	for k := range objB {
+		if _, ok := objA[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		va, inA := objA[k]
+		vb, inB := objB[k]
+		p := path + Pointer(k)
+		switch {
+		case !inB:
+			patch = patch.Remove(p)
+		case !inA:
+			patch = patch.Add(p, vb)
+		default:
+			patch = diff(patch, p, va, vb)
+		}
+	}
+	return patch
+}
+
+// toJSONValue returns v as the generic JSON value, like it is decoded into any.
+func toJSONValue(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	err = json.Unmarshal(data, &value)
+	return value, err
+}
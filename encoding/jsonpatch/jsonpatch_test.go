@@ -0,0 +1,81 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPatch(t *testing.T) {
+	patch := New().
+		Add("/labels/-", "bug").
+		Replace("/description", nil).
+		Remove("/milestone").
+		Move("/a", "/b").
+		Copy("/c", "/d").
+		Test(Pointer("a/b", "m~n"), 0)
+
+	b, err := json.Marshal(patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"op":"add","path":"/labels/-","value":"bug"},{"op":"replace","path":"/description","value":null},` +
+		`{"op":"remove","path":"/milestone"},{"op":"move","path":"/b","from":"/a"},{"op":"copy","path":"/d","from":"/c"},` +
+		`{"op":"test","path":"/a~1b/m~0n","value":0}]`
+	if string(b) != want {
+		t.Errorf("Marshal() failed: target=%s want=%s", b, want)
+	}
+
+	var decoded Patch
+	if err = json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(patch) || decoded[0].Value != "bug" || decoded[3].From != "/a" {
+		t.Errorf("Unmarshal() failed: %+v", decoded)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	type issue struct {
+		Title  string            `json:"title"`
+		Labels []string          `json:"labels,omitempty"`
+		Meta   map[string]string `json:"meta,omitempty"`
+	}
+
+	tests := []struct {
+		original any
+		modified any
+		want     string
+	}{
+		{
+			original: issue{Title: "a", Labels: []string{"bug"}, Meta: map[string]string{"x": "1", "y": "2"}},
+			modified: issue{Title: "b", Labels: []string{"bug"}, Meta: map[string]string{"x": "1", "z": "3"}},
+			want:     `[{"op":"remove","path":"/meta/y"},{"op":"add","path":"/meta/z","value":"3"},{"op":"replace","path":"/title","value":"b"}]`,
+		},
+		{
+			original: issue{Title: "a", Labels: []string{"bug"}},
+			modified: issue{Title: "a"},
+			want:     `[{"op":"remove","path":"/labels"}]`,
+		},
+		{
+			original: map[string]any{"a/b": []int{1}},
+			modified: map[string]any{"a/b": []int{1, 2}},
+			want:     `[{"op":"replace","path":"/a~1b","value":[1,2]}]`,
+		},
+		{
+			original: issue{Title: "a"},
+			modified: issue{Title: "a"},
+			want:     `[]`,
+		},
+	}
+
+	for i, v := range tests {
+		patch, err := Diff(v.original, v.modified)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := json.Marshal(patch)
+		if string(b) != v.want {
+			t.Errorf("index: %d, Diff() failed: target=%s want=%s", i, b, v.want)
+		}
+	}
+}
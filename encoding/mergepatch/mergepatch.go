@@ -0,0 +1,113 @@
+// Package mergepatch builds JSON Merge Patch (RFC 7386) documents, sent as application/merge-patch+json.
+package mergepatch
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/zdz1715/ghttp/encoding"
+)
+
+// Name is the name registered for the merge-patch codec.
+const Name = "merge-patch"
+
+// ContentType is the media type of a JSON Merge Patch document.
+const ContentType = "application/merge-patch+json"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec is a Codec implementation with json for JSON Merge Patch documents.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (codec) Name() string {
+	return Name
+}
+
+// Patch is a JSON Merge Patch object, a null member removes the member of the target.
+// Pass it as args of Client.Invoke to send it with ContentType.
+//
+//	patch := mergepatch.New().Set("title", "new title").Delete("description")
+type Patch map[string]any
+
+func New() Patch {
+	return Patch{}
+}
+
+// ContentType implements ghttp.ContentTyper.
+func (p Patch) ContentType() string {
+	return ContentType
+}
+
+// Set sets the member, a nested Patch merges the member object.
+func (p Patch) Set(name string, value any) Patch {
+	p[name] = value
+	return p
+}
+
+// Delete removes the member of the target.
+func (p Patch) Delete(name string) Patch {
+	p[name] = nil
+	return p
+}
+
+// Diff returns the merge patch that transforms the JSON object of original into the JSON object of modified.
+// Arrays are replaced as a whole, and a member can not be set to null, as defined by RFC 7386.
+func Diff(original, modified any) (Patch, error) {
+	a, err := toJSONObject(original)
+	if err != nil {
+		return nil, err
+	}
+	b, err := toJSONObject(modified)
+	if err != nil {
+		return nil, err
+	}
+	return diff(a, b), nil
+}
+
+func diff(a, b map[string]any) Patch {
+	patch := New()
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			patch.Delete(k)
+		}
+	}
+	for k, vb := range b {
+		va, ok := a[k]
+		if ok && reflect.DeepEqual(va, vb) {
+			continue
+		}
+		objA, okA := va.(map[string]any)
+		objB, okB := vb.(map[string]any)
+		if okA && okB {
+			patch.Set(k, diff(objA, objB))
+			continue
+		}
+		patch.Set(k, vb)
+	}
+	return patch
+}
+
+func toJSONObject(v any) (map[string]any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]any
+	if err = json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		obj = map[string]any{}
+	}
+	return obj, nil
+}
@@ -0,0 +1,51 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	type author struct {
+		GivenName  string `json:"givenName"`
+		FamilyName string `json:"familyName,omitempty"`
+	}
+	type document struct {
+		Title   string   `json:"title"`
+		Author  author   `json:"author"`
+		Tags    []string `json:"tags"`
+		Content string   `json:"content"`
+		Phone   string   `json:"phoneNumber,omitempty"`
+	}
+
+	// the example of RFC 7386 section 3
+	original := document{
+		Title:   "Goodbye!",
+		Author:  author{GivenName: "John", FamilyName: "Doe"},
+		Tags:    []string{"example", "sample"},
+		Content: "This will be unchanged",
+	}
+	modified := document{
+		Title:   "Hello!",
+		Author:  author{GivenName: "John"},
+		Tags:    []string{"example"},
+		Content: "This will be unchanged",
+		Phone:   "+01-123-456-7890",
+	}
+
+	patch, err := Diff(original, modified)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(patch)
+	want := `{"author":{"familyName":null},"phoneNumber":"+01-123-456-7890","tags":["example"],"title":"Hello!"}`
+	if string(b) != want {
+		t.Errorf("Diff() failed: target=%s want=%s", b, want)
+	}
+
+	b, _ = json.Marshal(New().Set("title", "Hello!").Delete("phoneNumber"))
+	want = `{"phoneNumber":null,"title":"Hello!"}`
+	if string(b) != want {
+		t.Errorf("Patch failed: target=%s want=%s", b, want)
+	}
+}
@@ -0,0 +1,51 @@
+package msgpack
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/zdz1715/ghttp/encoding"
+)
+
+// Name is the name registered for the msgpack codec.
+const Name = "msgpack"
+
+// fallbackTag is used if the struct field has no msgpack tag.
+const fallbackTag = "json"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec is a Codec implementation with msgpack.
+type codec struct{}
+
+func (c codec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c codec) Unmarshal(data []byte, v interface{}) error {
+	return c.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (codec) NewEncoder(w io.Writer) encoding.Encoder {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag(fallbackTag)
+	return enc
+}
+
+func (codec) NewDecoder(r io.Reader) encoding.Decoder {
+	dec := msgpack.NewDecoder(r)
+	dec.SetCustomStructTag(fallbackTag)
+	return dec
+}
+
+func (codec) Name() string {
+	return Name
+}
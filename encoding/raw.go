@@ -0,0 +1,38 @@
+package encoding
+
+import (
+	"encoding"
+	"io"
+)
+
+// NewRawEncoder returns an Encoder which writes the result of marshal to w,
+// it is used by the codecs which do not transform the body, e.g. text and binary.
+func NewRawEncoder(w io.Writer, marshal func(v interface{}) ([]byte, error)) Encoder {
+	return EncoderFunc(func(v interface{}) error {
+		data, err := marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+}
+
+// NewRawDecoder returns a Decoder which copies r to an io.Writer without buffering,
+// other values are unmarshaled from the whole stream.
+func NewRawDecoder(r io.Reader, unmarshal func(data []byte, v interface{}) error) Decoder {
+	return DecoderFunc(func(v interface{}) error {
+		switch t := v.(type) {
+		case *string, *[]byte, *interface{}, encoding.BinaryUnmarshaler, encoding.TextUnmarshaler:
+			// buffered by unmarshal
+		case io.Writer:
+			_, err := io.Copy(t, r)
+			return err
+		}
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		return unmarshal(data, v)
+	})
+}
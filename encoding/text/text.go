@@ -0,0 +1,76 @@
+package text
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+
+	gencoding "github.com/zdz1715/ghttp/encoding"
+)
+
+// Name is the name registered for the text codec.
+const Name = "text"
+
+func init() {
+	gencoding.RegisterCodec(codec{})
+}
+
+// codec is a Codec implementation with plain text, the body is not transformed.
+type codec struct{}
+
+// Marshal supports string, []byte and encoding.TextMarshaler.
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(t), nil
+	case *string:
+		if t == nil {
+			return nil, nil
+		}
+		return []byte(*t), nil
+	case []byte:
+		return t, nil
+	case *[]byte:
+		if t == nil {
+			return nil, nil
+		}
+		return *t, nil
+	case encoding.TextMarshaler:
+		return t.MarshalText()
+	}
+	return nil, fmt.Errorf("text: Marshal() unsupported type %T", v)
+}
+
+// Unmarshal supports *string, *[]byte, *any (string), io.Writer and encoding.TextUnmarshaler.
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	switch t := v.(type) {
+	case *string:
+		*t = string(data)
+	case *[]byte:
+		*t = append((*t)[:0], data...)
+	case *interface{}:
+		*t = string(data)
+	case encoding.TextUnmarshaler:
+		return t.UnmarshalText(data)
+	case io.Writer:
+		_, err := t.Write(data)
+		return err
+	default:
+		return fmt.Errorf("text: Unmarshal() unsupported type %T", v)
+	}
+	return nil
+}
+
+func (c codec) NewEncoder(w io.Writer) gencoding.Encoder {
+	return gencoding.NewRawEncoder(w, c.Marshal)
+}
+
+func (c codec) NewDecoder(r io.Reader) gencoding.Decoder {
+	return gencoding.NewRawDecoder(r, c.Unmarshal)
+}
+
+func (codec) Name() string {
+	return Name
+}
@@ -0,0 +1,56 @@
+package toml
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"github.com/zdz1715/ghttp/encoding"
+)
+
+// Name is the name registered for the toml codec.
+const Name = "toml"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec is a Codec implementation with toml, struct fields use the toml tag.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return toml.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return toml.Unmarshal(data, v)
+}
+
+func (codec) NewEncoder(w io.Writer) encoding.Encoder {
+	return toml.NewEncoder(w)
+}
+
+func (codec) NewDecoder(r io.Reader) encoding.Decoder {
+	return toml.NewDecoder(r)
+}
+
+func (codec) Name() string {
+	return Name
+}
+
+// Indent returns data with indented tables and arrays, it is human-readable.
+func Indent(data []byte) ([]byte, error) {
+	var v map[string]interface{}
+	if err := toml.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.SetIndentTables(true)
+	enc.SetArraysMultiline(true)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -1,7 +1,12 @@
 package xml
 
 import (
+	"bytes"
 	"encoding/xml"
+	"fmt"
+	"io"
+
+	"golang.org/x/text/encoding/htmlindex"
 
 	"github.com/zdz1715/ghttp/encoding"
 )
@@ -13,17 +18,37 @@ func init() {
 	encoding.RegisterCodec(codec{})
 }
 
-// codec is a Codec implementation with xml.
+// codec is a Codec implementation with xml, a non-UTF-8 encoding of the XML declaration
+// (e.g. GBK, GB18030) is decoded by CharsetReader.
 type codec struct{}
 
 func (codec) Marshal(v interface{}) ([]byte, error) {
 	return xml.Marshal(v)
 }
 
-func (codec) Unmarshal(data []byte, v interface{}) error {
-	return xml.Unmarshal(data, v)
+func (c codec) Unmarshal(data []byte, v interface{}) error {
+	return c.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (codec) NewEncoder(w io.Writer) encoding.Encoder {
+	return xml.NewEncoder(w)
+}
+
+func (codec) NewDecoder(r io.Reader) encoding.Decoder {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = CharsetReader
+	return dec
 }
 
 func (codec) Name() string {
 	return Name
 }
+
+// CharsetReader returns a reader that converts input from charset to UTF-8, it can be used as xml.Decoder.CharsetReader.
+func CharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("xml: unsupported charset: %s", charset)
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
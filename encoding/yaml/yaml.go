@@ -1,6 +1,9 @@
 package yaml
 
 import (
+	"errors"
+	"io"
+
 	"github.com/zdz1715/ghttp/encoding"
 	"gopkg.in/yaml.v3"
 )
@@ -26,3 +29,24 @@ func (codec) Unmarshal(data []byte, v interface{}) error {
 func (codec) Name() string {
 	return Name
 }
+
+func (codec) NewEncoder(w io.Writer) encoding.Encoder {
+	return encoding.EncoderFunc(func(v interface{}) error {
+		enc := yaml.NewEncoder(w)
+		if err := enc.Encode(v); err != nil {
+			_ = enc.Close()
+			return err
+		}
+		return enc.Close()
+	})
+}
+
+func (codec) NewDecoder(r io.Reader) encoding.Decoder {
+	return encoding.DecoderFunc(func(v interface{}) error {
+		// an empty document is not an error, like yaml.Unmarshal
+		if err := yaml.NewDecoder(r).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	})
+}
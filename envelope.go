@@ -0,0 +1,125 @@
+package ghttp
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/zdz1715/ghttp/encoding"
+	"github.com/zdz1715/ghttp/encoding/json"
+)
+
+// Envelope describes the business envelope of response bodies, e.g. {"code":0,"msg":"","data":{...}}.
+// The zero value uses the fields code, msg and data, and code 0 is successful.
+type Envelope struct {
+	CodeField    string
+	MessageField string
+	DataField    string
+
+	// Success reports whether the business code is successful, the code is formatted by fmt.Sprint,
+	// e.g. "0", "200", "true" or "OK".
+	Success func(code string) bool
+}
+
+// WithEnvelope decode the response body as envelope with the codec of the response, return
+// a *BusinessError if the business code is not successful, and bind only the data field to reply.
+// The codec must decode into map[string]any, e.g. json, yaml, msgpack, cbor and toml.
+// If reply is nil the business code is still checked, and the body is kept readable for the caller.
+// The bodies of non-2xx responses are bound by WithNot2xxError as is.
+func WithEnvelope(envelope Envelope) ClientOption {
+	return func(c *clientOptions) {
+		if envelope.CodeField == "" {
+			envelope.CodeField = "code"
+		}
+		if envelope.MessageField == "" {
+			envelope.MessageField = "msg"
+		}
+		if envelope.DataField == "" {
+			envelope.DataField = "data"
+		}
+		if envelope.Success == nil {
+			envelope.Success = func(code string) bool {
+				return code == "0"
+			}
+		}
+		c.envelope = &envelope
+	}
+}
+
+// unwrap decodes body as the envelope and binds its data to reply.
+func (e *Envelope) unwrap(codec encoding.Codec, response *http.Response, body []byte, reply any) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	fields, err := e.decode(codec, body)
+	if err != nil {
+		return fmt.Errorf("response: decode envelope: %w", err)
+	}
+
+	code, ok := fields[e.CodeField]
+	if !ok {
+		return fmt.Errorf("response: envelope field %q not found", e.CodeField)
+	}
+	if codeString := fmt.Sprint(code); !e.Success(codeString) {
+		businessErr := &BusinessError{
+			StatusCode: response.StatusCode,
+			Code:       codeString,
+			Data:       fields[e.DataField],
+		}
+		if message, ok := fields[e.MessageField]; ok && message != nil {
+			businessErr.Message = fmt.Sprint(message)
+		}
+		if response.Request != nil {
+			businessErr.URL = response.Request.URL
+			businessErr.Method = response.Request.Method
+		}
+		return businessErr
+	}
+
+	data, ok := fields[e.DataField]
+	if !ok || data == nil || reply == nil {
+		return nil
+	}
+	var dataBytes []byte
+	if raw, ok := data.(stdjson.RawMessage); ok {
+		dataBytes = raw
+	} else if dataBytes, err = codec.Marshal(data); err != nil {
+		return fmt.Errorf("response: encode envelope data: %w", err)
+	}
+	return codec.Unmarshal(dataBytes, reply)
+}
+
+// decode returns the fields of the envelope, the fields of json are kept raw, so that data is
+// bound with the options of the codec and numbers keep their precision.
+func (e *Envelope) decode(codec encoding.Codec, body []byte) (map[string]any, error) {
+	if codec.Name() != json.Name {
+		fields := make(map[string]any)
+		err := codec.Unmarshal(body, &fields)
+		return fields, err
+	}
+
+	var raws map[string]stdjson.RawMessage
+	if err := codec.Unmarshal(body, &raws); err != nil {
+		return nil, err
+	}
+	fields := make(map[string]any, len(raws))
+	for name, raw := range raws {
+		if bytes.Equal(raw, []byte("null")) {
+			fields[name] = nil
+			continue
+		}
+		if name != e.CodeField && name != e.MessageField {
+			fields[name] = raw
+			continue
+		}
+		dec := stdjson.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		fields[name] = v
+	}
+	return fields, nil
+}
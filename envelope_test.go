@@ -0,0 +1,144 @@
+package ghttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newEnvelopeClient(contentType, body string, envelope Envelope) *Client {
+	return NewClient(
+		WithEndpoint("http://example.com"),
+		WithEnvelope(envelope),
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {contentType}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Request:    req,
+			}, nil
+		})),
+	)
+}
+
+func TestWithEnvelope(t *testing.T) {
+	type user struct {
+		ID   uint64 `json:"id" yaml:"id"`
+		Name string `json:"name" yaml:"name"`
+	}
+
+	var reply user
+	client := newEnvelopeClient("application/json", `{"code":0,"msg":"","data":{"id":18446744073709551615,"name":"ghttp"}}`, Envelope{})
+	if _, err := client.Invoke(context.Background(), http.MethodGet, "/users/1", nil, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.ID != 18446744073709551615 || reply.Name != "ghttp" {
+		t.Errorf("json data failed: %+v", reply)
+	}
+
+	// custom fields and success rule
+	reply = user{}
+	client = newEnvelopeClient("application/yaml", "status: OK\nmessage: success\nresult:\n  id: 1\n  name: ghttp\n", Envelope{
+		CodeField:    "status",
+		MessageField: "message",
+		DataField:    "result",
+		Success: func(code string) bool {
+			return code == "OK"
+		},
+	})
+	if _, err := client.Invoke(context.Background(), http.MethodGet, "/users/1", nil, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.ID != 1 || reply.Name != "ghttp" {
+		t.Errorf("yaml data failed: %+v", reply)
+	}
+
+	tests := []struct {
+		body    string
+		reply   any
+		code    string
+		message string
+		err     string
+	}{
+		{body: `{"code":10001,"msg":"user not found","data":null}`, reply: &user{}, code: "10001", message: "user not found"},
+		{body: `{"code":"403","msg":"forbidden"}`, reply: nil, code: "403", message: "forbidden"},
+		{body: `{"msg":"ok","data":{}}`, reply: &user{}, err: `envelope field "code" not found`},
+		{body: `[1,2]`, reply: &user{}, err: "decode envelope"},
+	}
+	for i, v := range tests {
+		client = newEnvelopeClient("application/json", v.body, Envelope{})
+		_, err := client.Invoke(context.Background(), http.MethodPost, "/users", nil, v.reply)
+		if v.err != "" {
+			if err == nil || !strings.Contains(err.Error(), v.err) {
+				t.Errorf("index: %d, Invoke() failed: err=%v want=%s", i, err, v.err)
+			}
+			continue
+		}
+		var businessErr *BusinessError
+		if !errors.As(err, &businessErr) || !IsBusinessError(err) {
+			t.Fatalf("index: %d, BusinessError failed: %v", i, err)
+		}
+		if businessErr.Code != v.code || businessErr.Message != v.message || businessErr.StatusCode != http.StatusOK {
+			t.Errorf("index: %d, BusinessError failed: %+v", i, businessErr)
+		}
+		want := `POST "http://example.com/users" 200: business code ` + v.code + ": " + v.message
+		if err.Error() != want {
+			t.Errorf("index: %d, Error() failed: target=%s want=%s", i, err.Error(), want)
+		}
+	}
+}
+
+type envelopeErrorReply struct {
+	Message string `json:"message"`
+}
+
+func (e *envelopeErrorReply) String() string {
+	return e.Message
+}
+
+func TestWithEnvelopeNot2xx(t *testing.T) {
+	newClient := func(statusCode int, contentType, body string, opts ...ClientOption) *Client {
+		return NewClient(append([]ClientOption{
+			WithEndpoint("http://example.com"),
+			WithEnvelope(Envelope{}),
+			WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: statusCode,
+					Header:     http.Header{"Content-Type": {contentType}},
+					Body:       io.NopCloser(strings.NewReader(body)),
+					Request:    req,
+				}, nil
+			})),
+		}, opts...)...)
+	}
+
+	// the error body is bound by WithNot2xxError as is
+	client := newClient(http.StatusNotFound, "application/json", `{"message":"not found"}`, WithNot2xxError(func() Not2xxError {
+		return &envelopeErrorReply{}
+	}))
+	_, err := client.Invoke(context.Background(), http.MethodGet, "/users/1", nil, nil)
+	if e, ok := ConvertToHTTPNot2xxError(err); !ok || e.Err.String() != "not found" {
+		t.Errorf("not2xx error failed: %v", err)
+	}
+
+	// application/problem+json is decoded by default
+	client = newClient(http.StatusBadRequest, ProblemMediaType, `{"title":"Bad Request","status":400}`)
+	_, err = client.Invoke(context.Background(), http.MethodGet, "/users/1", nil, nil)
+	if problem, ok := ConvertToProblemDetails(err); !ok || problem.Title != "Bad Request" {
+		t.Errorf("problem details failed: %v", err)
+	}
+
+	// the body of a nil reply is kept readable
+	body := `{"code":0,"msg":"","data":{"id":1}}`
+	client = newClient(http.StatusOK, "application/json", body)
+	response, err := client.Invoke(context.Background(), http.MethodGet, "/users/1", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := io.ReadAll(response.Body); string(b) != body {
+		t.Errorf("response body failed: target=%s want=%s", b, body)
+	}
+}
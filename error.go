@@ -58,3 +58,53 @@ func ConvertToHTTPNot2xxError(err error) (*HTTPNot2xxError, bool) {
 	ok := errors.As(err, &e)
 	return e, ok
 }
+
+// BusinessError is returned by WithEnvelope if the business code of a response is not successful,
+// Data is the data field of the envelope, json.RawMessage for json.
+type BusinessError struct {
+	URL        *url.URL
+	Method     string
+	StatusCode int
+	Code       string
+	Message    string
+	Data       any
+}
+
+func (b BusinessError) Error() string {
+	var buf strings.Builder
+
+	if b.Method != "" {
+		buf.WriteString(b.Method)
+		buf.WriteByte(' ')
+	}
+
+	if b.URL != nil {
+		buf.WriteString(`"`)
+		buf.WriteString(b.URL.String())
+		buf.WriteString(`"`)
+		buf.WriteByte(' ')
+	}
+
+	buf.WriteString(strconv.Itoa(b.StatusCode))
+	buf.WriteString(": business code ")
+	buf.WriteString(b.Code)
+	if b.Message != "" {
+		buf.WriteString(": ")
+		buf.WriteString(b.Message)
+	}
+	return buf.String()
+}
+
+func IsBusinessError(err error) bool {
+	_, ok := ConvertToBusinessError(err)
+	return ok
+}
+
+func ConvertToBusinessError(err error) (*BusinessError, bool) {
+	if err == nil {
+		return nil, false
+	}
+	var e *BusinessError
+	ok := errors.As(err, &e)
+	return e, ok
+}
@@ -0,0 +1,80 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Location is the position of an error in the query.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is an entry of the errors of a GraphQL response.
+type Error struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	// Path is the field path of the error, the segments are strings (fields) and numbers (list indexes).
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Path) == 0 {
+		return "graphql: " + e.Message
+	}
+	return fmt.Sprintf("graphql: %s (path: %s)", e.Message, e.PathString())
+}
+
+// PathString returns the path joined by '.', e.g. project.issues.0.title.
+func (e *Error) PathString() string {
+	segments := make([]string, len(e.Path))
+	for i, segment := range e.Path {
+		segments[i] = fmt.Sprint(segment)
+	}
+	return strings.Join(segments, ".")
+}
+
+// Code returns extensions.code, it is set by most servers.
+func (e *Error) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// Errors is the errors of a GraphQL response, use errors.As to get an *Error.
+type Errors []*Error
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = strings.TrimPrefix(err.Error(), "graphql: ")
+	}
+	return fmt.Sprintf("graphql: %d errors: %s", len(e), strings.Join(messages, "; "))
+}
+
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// hasError reports whether err contains a GraphQL error with message or extensions.code.
+func hasError(err error, message, code string) bool {
+	var gqlErrs Errors
+	if !errors.As(err, &gqlErrs) {
+		return false
+	}
+	for _, e := range gqlErrs {
+		if e.Message == message || e.Code() == code {
+			return true
+		}
+	}
+	return false
+}
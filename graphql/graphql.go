@@ -0,0 +1,131 @@
+// Package graphql is a GraphQL client built on ghttp.Client.Invoke.
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/zdz1715/ghttp"
+)
+
+// accept prefers the GraphQL over HTTP media type, application/json is accepted by legacy servers.
+const accept = "application/graphql-response+json, application/json"
+
+// Client sends GraphQL operations to the endpoint path of a ghttp.Client.
+type Client struct {
+	client           *ghttp.Client
+	path             string
+	persistedQueries bool
+}
+
+type Option func(*Client)
+
+// WithPersistedQueries enable Automatic Persisted Queries (APQ), only the sha256 hash of the query is sent,
+// the query is sent again with the hash if the server responds with PersistedQueryNotFound.
+func WithPersistedQueries(enabled bool) Option {
+	return func(c *Client) {
+		c.persistedQueries = enabled
+	}
+}
+
+// NewClient returns a GraphQL client, path is the endpoint of the GraphQL API, e.g. /api/graphql.
+func NewClient(client *ghttp.Client, path string, opts ...Option) *Client {
+	c := &Client{
+		client: client,
+		path:   path,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Request is a GraphQL operation, Variables can contain *Upload to send files.
+type Request struct {
+	Query         string         `json:"query,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+	OperationName string         `json:"operationName,omitempty"`
+	Extensions    map[string]any `json:"extensions,omitempty"`
+}
+
+// Response is the GraphQL response, Data is the reply of Client.Do.
+type Response struct {
+	Data       any            `json:"data,omitempty"`
+	Errors     Errors         `json:"errors,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// Query sends query with variables and decodes data into reply.
+func (c *Client) Query(ctx context.Context, query string, variables map[string]any, reply any, opts ...ghttp.CallOption) error {
+	return c.Do(ctx, &Request{Query: query, Variables: variables}, reply, opts...)
+}
+
+// Do sends req and decodes data into reply. The errors of the response are returned as Errors,
+// reply is still decoded if the server returns partial data.
+func (c *Client) Do(ctx context.Context, req *Request, reply any, opts ...ghttp.CallOption) error {
+	if req == nil {
+		return errors.New("graphql: nil request")
+	}
+	uploads := findUploads(req.Variables)
+	if !c.persistedQueries || req.Query == "" || len(uploads) > 0 {
+		return c.send(ctx, req, uploads, reply, opts)
+	}
+
+	sum := sha256.Sum256([]byte(req.Query))
+	apq := *req
+	apq.Extensions = make(map[string]any, len(req.Extensions)+1)
+	for k, v := range req.Extensions {
+		apq.Extensions[k] = v
+	}
+	apq.Extensions["persistedQuery"] = map[string]any{
+		"version":    1,
+		"sha256Hash": hex.EncodeToString(sum[:]),
+	}
+	apq.Query = ""
+
+	err := c.send(ctx, &apq, nil, reply, opts)
+	switch {
+	case hasError(err, "PersistedQueryNotFound", "PERSISTED_QUERY_NOT_FOUND"):
+		// register the query with its hash
+		apq.Query = req.Query
+		return c.send(ctx, &apq, nil, reply, opts)
+	case hasError(err, "PersistedQueryNotSupported", "PERSISTED_QUERY_NOT_SUPPORTED"):
+		return c.send(ctx, req, nil, reply, opts)
+	}
+	return err
+}
+
+func (c *Client) send(ctx context.Context, req *Request, uploads []*upload, reply any, opts []ghttp.CallOption) error {
+	var args any
+	if len(uploads) > 0 {
+		m, err := newMultipart(req, uploads)
+		if err != nil {
+			return err
+		}
+		args = m
+	} else {
+		body, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		args = &ghttp.RawBody{
+			Reader:      bytes.NewReader(body),
+			ContentType: "application/json",
+		}
+	}
+
+	response := &Response{Data: reply}
+	opts = append([]ghttp.CallOption{ghttp.DefaultHeader("Accept", accept)}, opts...)
+	if _, err := c.client.Invoke(ctx, http.MethodPost, c.path, args, response, opts...); err != nil {
+		return err
+	}
+	if len(response.Errors) > 0 {
+		return response.Errors
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package graphql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zdz1715/ghttp"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(ghttp.NewClient(ghttp.WithEndpoint(server.URL)), "/api/graphql", opts...)
+}
+
+func TestClientDo(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/graphql" || r.Header.Get("Accept") != accept {
+			t.Errorf("request failed: path=%s accept=%s", r.URL.Path, r.Header.Get("Accept"))
+		}
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.OperationName != "getProject" || req.Variables["fullPath"] != "gitlab-org/gitlab" {
+			t.Errorf("request body failed: %+v", req)
+		}
+		w.Header().Set("Content-Type", "application/graphql-response+json")
+		_, _ = io.WriteString(w, `{"data":{"project":{"name":"GitLab","issues":[null]}},"errors":[{"message":"Forbidden",`+
+			`"locations":[{"line":1,"column":40}],"path":["project","issues",0],"extensions":{"code":"FORBIDDEN"}}]}`)
+	})
+
+	var reply struct {
+		Project struct {
+			Name string `json:"name"`
+		} `json:"project"`
+	}
+	err := client.Do(context.Background(), &Request{
+		Query:         `query getProject($fullPath: ID!) { project(fullPath: $fullPath) { name issues { title } } }`,
+		Variables:     map[string]any{"fullPath": "gitlab-org/gitlab"},
+		OperationName: "getProject",
+	}, &reply)
+
+	if reply.Project.Name != "GitLab" {
+		t.Errorf("partial data failed: target=%s want=GitLab", reply.Project.Name)
+	}
+	var gqlErr *Error
+	if !errors.As(err, &gqlErr) {
+		t.Fatalf("errors.As() failed: %v", err)
+	}
+	if gqlErr.Code() != "FORBIDDEN" || gqlErr.Locations[0].Line != 1 || gqlErr.PathString() != "project.issues.0" {
+		t.Errorf("Error failed: %+v", gqlErr)
+	}
+	want := "graphql: Forbidden (path: project.issues.0)"
+	if err.Error() != want {
+		t.Errorf("Error() failed: target=%s want=%s", err.Error(), want)
+	}
+}
+
+func TestPersistedQueries(t *testing.T) {
+	query := `{ currentUser { username } }`
+	sum := sha256.Sum256([]byte(query))
+	hash := hex.EncodeToString(sum[:])
+
+	var requests []Request
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		requests = append(requests, req)
+		persisted, _ := req.Extensions["persistedQuery"].(map[string]any)
+		if persisted["sha256Hash"] != hash {
+			t.Errorf("sha256Hash failed: target=%v want=%s", persisted["sha256Hash"], hash)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if req.Query == "" {
+			_, _ = io.WriteString(w, `{"errors":[{"message":"PersistedQueryNotFound","extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"data":{"currentUser":{"username":"root"}}}`)
+	}, WithPersistedQueries(true))
+
+	var reply map[string]any
+	if err := client.Query(context.Background(), query, nil, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 || requests[0].Query != "" || requests[1].Query != query {
+		t.Errorf("requests failed: %+v", requests)
+	}
+	if user, _ := reply["currentUser"].(map[string]any); user["username"] != "root" {
+		t.Errorf("reply failed: %+v", reply)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Fatal(err)
+		}
+		operations := `{"query":"mutation($files: [Upload!]!, $avatar: Upload) { upload(files: $files, avatar: $avatar) }",` +
+			`"variables":{"avatar":null,"files":[null,null]}}`
+		if target := r.FormValue("operations"); target != operations {
+			t.Errorf("operations failed: target=%s want=%s", target, operations)
+		}
+		fileMap := `{"0":["variables.avatar","variables.files.1"],"1":["variables.files.0"]}`
+		if target := r.FormValue("map"); target != fileMap {
+			t.Errorf("map failed: target=%s want=%s", target, fileMap)
+		}
+		for name, want := range map[string]string{"0": "avatar", "1": "readme"} {
+			f, header, err := r.FormFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, _ := io.ReadAll(f)
+			if string(b) != want || header.Filename != want+".txt" {
+				t.Errorf("file %s failed: target=%s %s want=%s", name, header.Filename, b, want)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"data":{"upload":true}}`)
+	})
+
+	avatar := &Upload{FileName: "avatar.txt", Reader: strings.NewReader("avatar")}
+	readme := &Upload{FileName: "readme.txt", Reader: strings.NewReader("readme")}
+	var reply struct {
+		Upload bool `json:"upload"`
+	}
+	err := client.Query(context.Background(),
+		`mutation($files: [Upload!]!, $avatar: Upload) { upload(files: $files, avatar: $avatar) }`,
+		map[string]any{"files": []*Upload{readme, avatar}, "avatar": avatar},
+		&reply,
+	)
+	if err != nil || !reply.Upload {
+		t.Errorf("upload failed: %v", err)
+	}
+}
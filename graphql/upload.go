@@ -0,0 +1,106 @@
+package graphql
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/zdz1715/ghttp"
+)
+
+// Upload is a file in the variables, the request is sent following the GraphQL multipart request spec
+// (https://github.com/jaydenseric/graphql-multipart-request-spec). The file is null in the operations.
+type Upload struct {
+	FileName    string
+	ContentType string
+	Reader      io.Reader
+	// Path is opened when the request is sent if Reader is nil.
+	Path string
+}
+
+func (*Upload) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+type upload struct {
+	file  *Upload
+	paths []string
+}
+
+var uploadType = reflect.TypeOf((*Upload)(nil))
+
+// findUploads returns the uploads in variables with their object paths, e.g. variables.files.0.
+func findUploads(variables map[string]any) []*upload {
+	if len(variables) == 0 {
+		return nil
+	}
+	var uploads []*upload
+	index := make(map[*Upload]*upload)
+	var walk func(path string, v reflect.Value)
+	walk = func(path string, v reflect.Value) {
+		for v.Kind() == reflect.Interface && !v.IsNil() {
+			v = v.Elem()
+		}
+		switch {
+		case v.Type() == uploadType:
+			file := v.Interface().(*Upload)
+			if file == nil {
+				return
+			}
+			// a file can be used by more than one variable
+			if u, ok := index[file]; ok {
+				u.paths = append(u.paths, path)
+				return
+			}
+			u := &upload{file: file, paths: []string{path}}
+			index[file] = u
+			uploads = append(uploads, u)
+		case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+			keys := v.MapKeys()
+			// the order of files is stable
+			sort.Slice(keys, func(i, j int) bool {
+				return keys[i].String() < keys[j].String()
+			})
+			for _, key := range keys {
+				walk(path+"."+key.String(), v.MapIndex(key))
+			}
+		case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+			for i := 0; i < v.Len(); i++ {
+				walk(path+"."+strconv.Itoa(i), v.Index(i))
+			}
+		}
+	}
+	walk("variables", reflect.ValueOf(variables))
+	return uploads
+}
+
+func newMultipart(req *Request, uploads []*upload) (*ghttp.Multipart, error) {
+	operations, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	fileMap := make(map[string][]string, len(uploads))
+	for i, u := range uploads {
+		fileMap[strconv.Itoa(i)] = u.paths
+	}
+	mapJSON, err := json.Marshal(fileMap)
+	if err != nil {
+		return nil, err
+	}
+
+	m := ghttp.NewMultipart().
+		AddField("operations", string(operations)).
+		AddField("map", string(mapJSON))
+	for i, u := range uploads {
+		m.AddPart(&ghttp.MultipartPart{
+			FieldName:   strconv.Itoa(i),
+			FileName:    u.file.FileName,
+			ContentType: u.file.ContentType,
+			Reader:      u.file.Reader,
+			Path:        u.file.Path,
+		})
+	}
+	return m, nil
+}
@@ -0,0 +1,38 @@
+package jsonrpc
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Error codes defined by the JSON-RPC 2.0 specification,
+// -32000 to -32099 are reserved for implementation-defined server errors.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+// Error is the error object of a JSON-RPC response.
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Data) == 0 {
+		return fmt.Sprintf("jsonrpc: %d %s", e.Code, e.Message)
+	}
+	return fmt.Sprintf("jsonrpc: %d %s: %s", e.Code, e.Message, e.Data)
+}
+
+// UnmarshalData decodes Data into v.
+func (e *Error) UnmarshalData(v any) error {
+	if len(e.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(e.Data, v)
+}
@@ -0,0 +1,229 @@
+// Package jsonrpc is a JSON-RPC 2.0 client built on ghttp.Client.Invoke.
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/zdz1715/ghttp"
+)
+
+const version = "2.0"
+
+// maxDrainSize is the maximum size of the response body of a notification read to reuse the connection.
+const maxDrainSize = 64 << 10
+
+// Client calls the JSON-RPC methods of the endpoint path of a ghttp.Client, it is safe for concurrent use.
+type Client struct {
+	client *ghttp.Client
+	path   string
+	nextID func() any
+}
+
+type Option func(*Client)
+
+// WithIDGenerator generate request IDs by f, it must return a string or a number unique in a batch.
+// The default IDs are increasing numbers.
+func WithIDGenerator(f func() any) Option {
+	return func(c *Client) {
+		c.nextID = f
+	}
+}
+
+// NewClient returns a JSON-RPC client, path is the endpoint of the JSON-RPC API.
+func NewClient(client *ghttp.Client, path string, opts ...Option) *Client {
+	c := &Client{
+		client: client,
+		path:   path,
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	if c.nextID == nil {
+		var id atomic.Uint64
+		c.nextID = func() any {
+			return id.Add(1)
+		}
+	}
+	return c
+}
+
+// Request is a JSON-RPC request, it is a notification if ID is nil.
+type Request struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      any    `json:"id,omitempty"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// Response is a JSON-RPC response.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// BatchElem is a call of Client.BatchCall, Result and Error are set when the response is received.
+type BatchElem struct {
+	Method string
+	Params any
+	// Result is the pointer the result is decoded into.
+	Result any
+	// Error is the *Error of the response, or an error if the response is missing or cannot be decoded.
+	Error error
+}
+
+// Call calls method with params (an array or an object) and decodes the result into result.
+// The error object of the response is returned as *Error.
+func (c *Client) Call(ctx context.Context, method string, params any, result any, opts ...ghttp.CallOption) error {
+	req := c.newRequest(method, params)
+	var response Response
+	if err := c.send(ctx, req, &response, opts); err != nil {
+		return err
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if !sameID(response.ID, req.ID) {
+		return fmt.Errorf("jsonrpc: unexpected response id %s", response.ID)
+	}
+	return decodeResult(response.Result, result)
+}
+
+// Notify sends a notification, the server does not reply.
+func (c *Client) Notify(ctx context.Context, method string, params any, opts ...ghttp.CallOption) error {
+	return c.send(ctx, &Request{JSONRPC: version, Method: method, Params: params}, nil, opts)
+}
+
+// BatchCall sends the calls in one HTTP request, the responses are matched by ID in any order.
+// The error is returned only if the batch fails, the error of each call is set to BatchElem.Error.
+func (c *Client) BatchCall(ctx context.Context, batch []BatchElem, opts ...ghttp.CallOption) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	reqs := make([]*Request, len(batch))
+	index := make(map[string]int, len(batch))
+	for i, elem := range batch {
+		reqs[i] = c.newRequest(elem.Method, elem.Params)
+		key, err := idKey(reqs[i].ID)
+		if err != nil {
+			return err
+		}
+		if _, ok := index[key]; ok {
+			return fmt.Errorf("jsonrpc: duplicate request id %s", key)
+		}
+		index[key] = i
+	}
+
+	var raw json.RawMessage
+	if err := c.send(ctx, reqs, &raw, opts); err != nil {
+		return err
+	}
+
+	var responses []*Response
+	if raw = bytes.TrimSpace(raw); len(raw) > 0 && raw[0] == '{' {
+		// the batch is rejected with a single response, e.g. parse error
+		var response Response
+		if err := json.Unmarshal(raw, &response); err != nil {
+			return err
+		}
+		if response.Error != nil {
+			return response.Error
+		}
+		responses = append(responses, &response)
+	} else if err := json.Unmarshal(raw, &responses); err != nil {
+		return err
+	}
+
+	received := make([]bool, len(batch))
+	for _, response := range responses {
+		key, err := idKey(response.ID)
+		if err != nil {
+			continue
+		}
+		i, ok := index[key]
+		if !ok {
+			continue
+		}
+		received[i] = true
+		if response.Error != nil {
+			batch[i].Error = response.Error
+			continue
+		}
+		batch[i].Error = decodeResult(response.Result, batch[i].Result)
+	}
+	for i := range batch {
+		if !received[i] {
+			batch[i].Error = fmt.Errorf("jsonrpc: missing response for id %v", reqs[i].ID)
+		}
+	}
+	return nil
+}
+
+func (c *Client) newRequest(method string, params any) *Request {
+	return &Request{
+		JSONRPC: version,
+		ID:      c.nextID(),
+		Method:  method,
+		Params:  params,
+	}
+}
+
+func (c *Client) send(ctx context.Context, req any, reply any, opts []ghttp.CallOption) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	args := &ghttp.RawBody{
+		Reader:      bytes.NewReader(body),
+		ContentType: "application/json",
+	}
+	opts = append([]ghttp.CallOption{ghttp.DefaultHeader("Accept", "application/json")}, opts...)
+	response, err := c.client.Invoke(ctx, http.MethodPost, c.path, args, reply, opts...)
+	if err != nil {
+		return err
+	}
+	if reply == nil {
+		// the body of a notification is not bound, drain it so the connection can be reused
+		_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, maxDrainSize))
+		return response.Body.Close()
+	}
+	return nil
+}
+
+func decodeResult(data json.RawMessage, result any) error {
+	if result == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
+
+// idKey returns the canonical JSON of id, so a number ID matches whatever the server formatting is.
+func idKey(id any) (string, error) {
+	if raw, ok := id.(json.RawMessage); ok {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		// keep the precision of large numbers
+		dec.UseNumber()
+		if err := dec.Decode(&id); err != nil {
+			return "", err
+		}
+	}
+	if id == nil {
+		return "", errors.New("jsonrpc: null id")
+	}
+	b, err := json.Marshal(id)
+	return string(b), err
+}
+
+func sameID(raw json.RawMessage, id any) bool {
+	k1, err1 := idKey(raw)
+	k2, err2 := idKey(id)
+	return err1 == nil && err2 == nil && k1 == k2
+}
@@ -0,0 +1,149 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zdz1715/ghttp"
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  []int           `json:"params"`
+}
+
+// handle adds the params, unknown methods return CodeMethodNotFound.
+func handle(req rpcRequest) map[string]any {
+	response := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+	if req.Method != "add" {
+		response["error"] = map[string]any{"code": CodeMethodNotFound, "message": "Method not found", "data": req.Method}
+		return response
+	}
+	var sum int
+	for _, v := range req.Params {
+		sum += v
+	}
+	response["result"] = sum
+	return response
+}
+
+func newTestClient(t *testing.T, opts ...Option) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		if body[0] != '[' {
+			var req rpcRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				t.Fatal(err)
+			}
+			_ = json.NewEncoder(w).Encode(handle(req))
+			return
+		}
+		var reqs []rpcRequest
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			t.Fatal(err)
+		}
+		// reply in reverse order, the last request has no response
+		var responses []map[string]any
+		for i := len(reqs) - 2; i >= 0; i-- {
+			responses = append(responses, handle(reqs[i]))
+		}
+		_ = json.NewEncoder(w).Encode(responses)
+	}))
+	t.Cleanup(server.Close)
+	return NewClient(ghttp.NewClient(ghttp.WithEndpoint(server.URL)), "/rpc", opts...)
+}
+
+func TestCall(t *testing.T) {
+	client := newTestClient(t)
+
+	var sum int
+	if err := client.Call(context.Background(), "add", []int{1, 2, 3}, &sum); err != nil || sum != 6 {
+		t.Errorf("Call() failed: target=%d want=6, %v", sum, err)
+	}
+
+	err := client.Call(context.Background(), "sub", []int{1}, &sum)
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != CodeMethodNotFound {
+		t.Fatalf("Call() error failed: %v", err)
+	}
+	var method string
+	if err = rpcErr.UnmarshalData(&method); err != nil || method != "sub" {
+		t.Errorf("UnmarshalData() failed: target=%s want=sub, %v", method, err)
+	}
+}
+
+func TestBatchCall(t *testing.T) {
+	var id int
+	client := newTestClient(t, WithIDGenerator(func() any {
+		id++
+		return fmt.Sprintf("req-%d", id)
+	}))
+
+	var a, b int
+	batch := []BatchElem{
+		{Method: "add", Params: []int{1, 2}, Result: &a},
+		{Method: "unknown"},
+		{Method: "add", Params: []int{3, 4}, Result: &b},
+		{Method: "add", Params: []int{5}},
+	}
+	if err := client.BatchCall(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+	if a != 3 || b != 7 || batch[0].Error != nil || batch[2].Error != nil {
+		t.Errorf("results failed: a=%d b=%d, %v %v", a, b, batch[0].Error, batch[2].Error)
+	}
+	var rpcErr *Error
+	if !errors.As(batch[1].Error, &rpcErr) || rpcErr.Code != CodeMethodNotFound {
+		t.Errorf("batch[1] failed: %v", batch[1].Error)
+	}
+	if batch[3].Error == nil {
+		t.Errorf("batch[3] failed: want missing response error")
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestNotify(t *testing.T) {
+	body := &closeRecorder{Reader: strings.NewReader("")}
+	client := NewClient(ghttp.NewClient(
+		ghttp.WithEndpoint("http://example.com"),
+		ghttp.WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			var r rpcRequest
+			if err := json.NewDecoder(req.Body).Decode(&r); err != nil || r.ID != nil || r.Method != "log" {
+				t.Errorf("notification failed: %+v %v", r, err)
+			}
+			return &http.Response{StatusCode: http.StatusNoContent, Header: http.Header{}, Body: body, Request: req}, nil
+		})),
+	), "/rpc")
+
+	if err := client.Notify(context.Background(), "log", []int{1}); err != nil {
+		t.Fatal(err)
+	}
+	if !body.closed {
+		t.Errorf("Notify() failed: the response body is not closed")
+	}
+}
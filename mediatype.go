@@ -0,0 +1,91 @@
+package ghttp
+
+import (
+	"errors"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MediaType is a media type (RFC 6838) or a media range of Accept, Type and Subtype are lower case.
+type MediaType struct {
+	Type    string
+	Subtype string
+	Params  map[string]string
+}
+
+// ParseMediaType parses a media type by mime.ParseMediaType, invalid parameters are ignored.
+func ParseMediaType(s string) (MediaType, error) {
+	mediaType, params, err := mime.ParseMediaType(s)
+	if err != nil && !errors.Is(err, mime.ErrInvalidMediaParameter) {
+		return MediaType{}, err
+	}
+	typ, subtype, ok := strings.Cut(mediaType, "/")
+	if !ok || typ == "" || subtype == "" {
+		return MediaType{}, errors.New("mime: expected type/subtype: " + s)
+	}
+	return MediaType{Type: typ, Subtype: subtype, Params: params}, nil
+}
+
+// FullType returns type/subtype without parameters.
+func (m MediaType) FullType() string {
+	return m.Type + "/" + m.Subtype
+}
+
+// Suffix returns the structured syntax suffix (RFC 6839), e.g. json of application/vnd.api+json.
+func (m MediaType) Suffix() string {
+	if i := strings.LastIndex(m.Subtype, "+"); i >= 0 {
+		return m.Subtype[i+1:]
+	}
+	return ""
+}
+
+// Quality returns the q parameter of a media range, default 1.
+func (m MediaType) Quality() float64 {
+	q, err := strconv.ParseFloat(m.Params["q"], 64)
+	if err != nil || q > 1 {
+		return 1
+	}
+	if q < 0 {
+		return 0
+	}
+	return q
+}
+
+// Match reports whether m, which can be a media range such as text/* or */*, matches mediaType.
+func (m MediaType) Match(mediaType MediaType) bool {
+	return (m.Type == "*" || m.Type == mediaType.Type) &&
+		(m.Subtype == "*" || m.Subtype == mediaType.Subtype)
+}
+
+func (m MediaType) String() string {
+	return mime.FormatMediaType(m.FullType(), m.Params)
+}
+
+// lookupKeys returns the registry keys of m from the most specific: full type, subtype,
+// structured suffix and wildcards.
+func (m MediaType) lookupKeys() []string {
+	keys := []string{m.FullType(), m.Subtype}
+	if suffix := m.Suffix(); suffix != "" {
+		keys = append(keys, suffix)
+	}
+	return append(keys, m.Type+"/*", "*/*")
+}
+
+// ParseAccept parses the media ranges of an Accept header, ordered by quality.
+// The ranges with q=0 and the invalid ranges are dropped.
+func ParseAccept(accept string) []MediaType {
+	var ranges []MediaType
+	for _, s := range strings.Split(accept, ",") {
+		m, err := ParseMediaType(strings.TrimSpace(s))
+		if err != nil || m.Quality() == 0 {
+			continue
+		}
+		ranges = append(ranges, m)
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].Quality() > ranges[j].Quality()
+	})
+	return ranges
+}
@@ -0,0 +1,153 @@
+package ghttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseMediaType(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    string
+		suffix  string
+		quality float64
+		invalid bool
+	}{
+		{s: "application/json", want: "application/json", quality: 1},
+		{s: "Application/Vnd.API+JSON; charset=utf-8", want: "application/vnd.api+json", suffix: "json", quality: 1},
+		{s: "text/*;q=0.5", want: "text/*", quality: 0.5},
+		{s: "application/xml; q=2; charset", want: "application/xml", quality: 1},
+		{s: "json", invalid: true},
+		{s: "", invalid: true},
+	}
+
+	for i, v := range tests {
+		m, err := ParseMediaType(v.s)
+		if v.invalid {
+			if err == nil {
+				t.Errorf("index: %d, ParseMediaType() failed: want error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("index: %d, ParseMediaType() failed: %v", i, err)
+		}
+		if m.FullType() != v.want || m.Suffix() != v.suffix || m.Quality() != v.quality {
+			t.Errorf("index: %d, ParseMediaType() failed: target=%s %s %v want=%s %s %v",
+				i, m.FullType(), m.Suffix(), m.Quality(), v.want, v.suffix, v.quality)
+		}
+	}
+}
+
+func TestParseAccept(t *testing.T) {
+	ranges := ParseAccept("text/html;q=0.8, application/json, invalid, */*;q=0.1, application/xml;q=0")
+	var target []string
+	for _, m := range ranges {
+		target = append(target, m.FullType())
+	}
+	want := "application/json,text/html,*/*"
+	if strings.Join(target, ",") != want {
+		t.Errorf("ParseAccept() failed: target=%s want=%s", strings.Join(target, ","), want)
+	}
+
+	json, _ := ParseMediaType("application/json")
+	if !ranges[2].Match(json) || !ranges[0].Match(json) || ranges[1].Match(json) {
+		t.Errorf("Match() failed: %+v", ranges)
+	}
+}
+
+func TestGetCodecByMediaType(t *testing.T) {
+	registry := NewCodecRegistry()
+	registry.RegisterByMediaType("application/vnd.upper+json", upperCodec{})
+	registry.RegisterByMediaType("image/*", upperCodec{})
+
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{contentType: "application/vnd.upper+json; charset=utf-8", want: "upper"},
+		{contentType: "application/vnd.other+json", want: "json"},
+		{contentType: "application/json-patch+json", want: "json-patch"},
+		{contentType: "text/x-unknown", want: ""},
+		{contentType: "text/xml", want: "xml"},
+		{contentType: "image/png", want: "upper"},
+		{contentType: "video/mp4", want: ""},
+		{contentType: "*/*", want: ""},
+		{contentType: "json", want: ""},
+	}
+
+	for i, v := range tests {
+		target := ""
+		if codec := registry.GetCodecByContentType(v.contentType); codec != nil {
+			target = codec.Name()
+		}
+		if target != v.want {
+			t.Errorf("index: %d, GetCodecByContentType(%s) failed: target=%s want=%s", i, v.contentType, target, v.want)
+		}
+	}
+
+	// the first supported media range of Accept
+	header := http.Header{"Accept": {"video/mp4, application/xml;q=0.5, application/json;q=0.9"}}
+	if codec, ok := registry.CodecForRequest(&http.Request{Header: header}, "Accept"); !ok || codec.Name() != "json" {
+		t.Errorf("CodecForRequest() failed: target=%s want=json", codec.Name())
+	}
+}
+
+func TestAcceptNegotiation(t *testing.T) {
+	registry := NewCodecRegistry()
+	registry.RegisterByMediaType("application/vnd.upper", upperCodec{})
+
+	var accepts []string
+	client := NewClient(
+		WithEndpoint("http://example.com"),
+		WithCodecs(registry),
+		WithAcceptNegotiation(true),
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			accepts = append(accepts, req.Header.Get("Accept"))
+			body, _ := io.ReadAll(req.Body)
+			if string(body) != `{"name":"ghttp"}` {
+				t.Errorf("request body failed: target=%s", body)
+			}
+			if req.Header.Get("Accept") != "*/*" {
+				return &http.Response{StatusCode: http.StatusNotAcceptable, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+			}
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": {"application/vnd.upper"}},
+				Body:       io.NopCloser(strings.NewReader("ok")),
+				Request:    req,
+			}, nil
+		})),
+	)
+
+	var reply string
+	if _, err := client.Invoke(context.Background(), http.MethodPost, "/", map[string]string{"name": "ghttp"}, &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply != "OK" {
+		t.Errorf("reply failed: target=%s want=OK", reply)
+	}
+	if len(accepts) != 3 || accepts[1] != "application/json" || accepts[2] != "*/*" {
+		t.Fatalf("Accept failed: %q", accepts)
+	}
+	first := ParseAccept(accepts[0])
+	if first[0].FullType() != "application/json" || !strings.Contains(accepts[0], "application/vnd.upper;q=0.9") ||
+		strings.Contains(accepts[0], "json-patch") {
+		t.Errorf("generated Accept failed: %s", accepts[0])
+	}
+
+	// Accept set by the caller is not negotiated
+	accepts = nil
+	response, err := client.Invoke(context.Background(), http.MethodPost, "/", map[string]string{"name": "ghttp"}, nil, &CallOptions{
+		BeforeHook: func(request *http.Request) error {
+			request.Header.Set("Accept", "application/xml")
+			return nil
+		},
+	})
+	if err != nil || response.StatusCode != http.StatusNotAcceptable || len(accepts) != 1 {
+		t.Errorf("caller Accept failed: %v %q", err, accepts)
+	}
+}
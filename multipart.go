@@ -0,0 +1,392 @@
+package ghttp
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FormTag is the struct tag used by Multipart.AddStruct.
+const FormTag = "form"
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// MultipartPart is a part of the multipart/form-data body.
+// A part with Path or Reader is a file, otherwise Value is sent as a form field.
+type MultipartPart struct {
+	FieldName   string
+	FileName    string
+	ContentType string
+	Header      textproto.MIMEHeader
+
+	Value  string
+	Path   string
+	Reader io.Reader
+}
+
+func (p *MultipartPart) isFile() bool {
+	return p.Path != "" || p.Reader != nil
+}
+
+func (p *MultipartPart) header() textproto.MIMEHeader {
+	h := make(textproto.MIMEHeader, len(p.Header)+2)
+	for k, v := range p.Header {
+		h[k] = v
+	}
+
+	fileName := p.FileName
+	if fileName == "" && p.Path != "" {
+		fileName = filepath.Base(p.Path)
+	}
+
+	if h.Get("Content-Disposition") == "" {
+		disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(p.FieldName))
+		if p.isFile() && fileName != "" {
+			disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(fileName))
+		}
+		h.Set("Content-Disposition", disposition)
+	}
+
+	contentType := p.ContentType
+	if contentType == "" && p.isFile() {
+		contentType = mime.TypeByExtension(filepath.Ext(fileName))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+	}
+	if contentType != "" && h.Get("Content-Type") == "" {
+		h.Set("Content-Type", contentType)
+	}
+	return h
+}
+
+func (p *MultipartPart) writeTo(w io.Writer) error {
+	switch {
+	case p.Reader != nil:
+		_, err := io.Copy(w, p.Reader)
+		return err
+	case p.Path != "":
+		f, err := os.Open(p.Path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		return err
+	default:
+		_, err := io.WriteString(w, p.Value)
+		return err
+	}
+}
+
+// Multipart is a multipart/form-data request body. Pass it as args of Client.Invoke,
+// the parts are streamed through an io.Pipe, so large files are never buffered in memory.
+type Multipart struct {
+	boundary string
+	parts    []*MultipartPart
+}
+
+func NewMultipart() *Multipart {
+	return &Multipart{
+		boundary: multipart.NewWriter(io.Discard).Boundary(),
+	}
+}
+
+// SetBoundary overrides the random boundary.
+func (m *Multipart) SetBoundary(boundary string) error {
+	// validated by multipart.Writer
+	if err := multipart.NewWriter(io.Discard).SetBoundary(boundary); err != nil {
+		return err
+	}
+	m.boundary = boundary
+	return nil
+}
+
+func (m *Multipart) Boundary() string {
+	return m.boundary
+}
+
+// ContentType returns the Content-Type with boundary, e.g. multipart/form-data; boundary=xxx
+func (m *Multipart) ContentType() string {
+	b := m.boundary
+	// We must quote the boundary if it contains any of the
+	// tspecials characters defined by RFC 2045, or space.
+	if strings.ContainsAny(b, `()<>@,;:\"/[]?= `) {
+		b = `"` + b + `"`
+	}
+	return "multipart/form-data; boundary=" + b
+}
+
+func (m *Multipart) Parts() []*MultipartPart {
+	return m.parts
+}
+
+// AddField add a form field.
+func (m *Multipart) AddField(name, value string) *Multipart {
+	return m.AddPart(&MultipartPart{
+		FieldName: name,
+		Value:     value,
+	})
+}
+
+// AddFile add a file part from path, the file is opened when the body is sent.
+func (m *Multipart) AddFile(name, path string) *Multipart {
+	return m.AddPart(&MultipartPart{
+		FieldName: name,
+		Path:      path,
+	})
+}
+
+// AddReader add a file part from io.Reader.
+func (m *Multipart) AddReader(name, fileName string, r io.Reader) *Multipart {
+	return m.AddPart(&MultipartPart{
+		FieldName: name,
+		FileName:  fileName,
+		Reader:    r,
+	})
+}
+
+// AddPart add a custom part, it can set per-part headers and content type.
+func (m *Multipart) AddPart(part *MultipartPart) *Multipart {
+	if part != nil {
+		m.parts = append(m.parts, part)
+	}
+	return m
+}
+
+// AddStruct add parts from the exported fields of struct v.
+//
+// The field name defaults to the struct field name but can be specified in the
+// "form" tag, followed by optional comma-separated options:
+//
+//	// Field is ignored.
+//	Field string `form:"-"`
+//
+//	// Field is skipped if empty.
+//	Field string `form:"name,omitempty"`
+//
+//	// Field is a file path, the file is uploaded.
+//	Field string `form:"file,file"`
+//
+// MultipartPart, *os.File and io.Reader fields are sent as files. Slice and
+// array fields are sent as multiple parts with the same name.
+func (m *Multipart) AddStruct(v any) error {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Struct {
+		return fmt.Errorf("multipart: AddStruct() unsupported kind input. Got %v", val.Kind())
+	}
+
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		tag := sf.Tag.Get(FormTag)
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseFormTag(tag)
+		if name == "" {
+			name = sf.Name
+		}
+		sv := val.Field(i)
+		if opts["omitempty"] && sv.IsZero() {
+			continue
+		}
+		if err := m.addValue(name, sv, opts["file"]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseFormTag(tag string) (string, map[string]bool) {
+	s := strings.Split(tag, ",")
+	opts := make(map[string]bool, len(s)-1)
+	for _, v := range s[1:] {
+		opts[v] = true
+	}
+	return s[0], opts
+}
+
+var readerType = reflect.TypeOf((*io.Reader)(nil)).Elem()
+
+func (m *Multipart) addValue(name string, v reflect.Value, isPath bool) error {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch vv := v.Interface().(type) {
+	case MultipartPart:
+		vv.FieldName = name
+		m.AddPart(&vv)
+		return nil
+	case *MultipartPart:
+		if vv != nil {
+			part := *vv
+			part.FieldName = name
+			m.AddPart(&part)
+		}
+		return nil
+	case time.Time:
+		m.AddField(name, vv.Format(time.RFC3339))
+		return nil
+	case []byte:
+		m.AddField(name, string(vv))
+		return nil
+	}
+
+	if v.Type().Implements(readerType) {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			return nil
+		}
+		// the file name is known from a Name method, e.g. *os.File
+		var fileName string
+		if named, ok := v.Interface().(interface{ Name() string }); ok {
+			fileName = filepath.Base(named.Name())
+		}
+		m.AddReader(name, fileName, v.Interface().(io.Reader))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		return m.addValue(name, v.Elem(), isPath)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := m.addValue(name, v.Index(i), isPath); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map, reflect.Struct, reflect.Func, reflect.Chan:
+		return fmt.Errorf("multipart: field %q unsupported kind %v", name, v.Kind())
+	}
+
+	value := fmt.Sprint(v.Interface())
+	if isPath {
+		m.AddFile(name, value)
+	} else {
+		m.AddField(name, value)
+	}
+	return nil
+}
+
+// WriteTo writes the whole multipart body to w.
+func (m *Multipart) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	mw := multipart.NewWriter(cw)
+	if err := mw.SetBoundary(m.boundary); err != nil {
+		return cw.n, err
+	}
+	for _, part := range m.parts {
+		pw, err := mw.CreatePart(part.header())
+		if err != nil {
+			return cw.n, err
+		}
+		if err = part.writeTo(pw); err != nil {
+			return cw.n, err
+		}
+	}
+	err := mw.Close()
+	return cw.n, err
+}
+
+// replayable reports whether the body can be written again, parts with a Reader are read once.
+func (m *Multipart) replayable() bool {
+	for _, part := range m.parts {
+		if part.Reader != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Reader returns the streaming body, the parts are written by a goroutine through an io.Pipe
+// which is started by the first Read, so nothing is opened if the body is never sent.
+func (m *Multipart) Reader() io.ReadCloser {
+	return newPipeReader(func(w io.Writer) error {
+		_, err := m.WriteTo(w)
+		return err
+	})
+}
+
+// pipeReader reads what write writes to an io.Pipe, the goroutine of write is started by the first Read.
+type pipeReader struct {
+	write func(w io.Writer) error
+
+	mu     sync.Mutex
+	pr     *io.PipeReader
+	closed bool
+}
+
+func newPipeReader(write func(w io.Writer) error) *pipeReader {
+	return &pipeReader{write: write}
+}
+
+func (p *pipeReader) reader() (*io.PipeReader, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, io.ErrClosedPipe
+	}
+	if p.pr == nil {
+		pr, pw := io.Pipe()
+		go func() {
+			_ = pw.CloseWithError(p.write(pw))
+		}()
+		p.pr = pr
+	}
+	return p.pr, nil
+}
+
+func (p *pipeReader) Read(b []byte) (int, error) {
+	pr, err := p.reader()
+	if err != nil {
+		return 0, err
+	}
+	return pr.Read(b)
+}
+
+func (p *pipeReader) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	if p.pr != nil {
+		return p.pr.Close()
+	}
+	return nil
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
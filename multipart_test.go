@@ -0,0 +1,169 @@
+package ghttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMultipart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var buf strings.Builder
+		buf.WriteString(r.FormValue("title"))
+		for _, name := range []string{"file", "avatar"} {
+			for _, fh := range r.MultipartForm.File[name] {
+				f, _ := fh.Open()
+				b, _ := io.ReadAll(f)
+				_ = f.Close()
+				buf.WriteString("|" + fh.Filename + ":" + fh.Header.Get("Content-Type") + ":" + string(b))
+			}
+		}
+		_, _ = w.Write([]byte(buf.String()))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("from path"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	type form struct {
+		Title  string         `form:"title"`
+		File   string         `form:"file,file"`
+		Ignore string         `form:"-"`
+		Avatar *MultipartPart `form:"avatar"`
+	}
+
+	tests := []struct {
+		body func() *Multipart
+		want string
+	}{
+		{
+			body: func() *Multipart {
+				return NewMultipart().
+					AddField("title", "hello").
+					AddFile("file", path).
+					AddReader("file", "b.bin", strings.NewReader("from reader"))
+			},
+			want: "hello|a.txt:text/plain; charset=utf-8:from path|b.bin:application/octet-stream:from reader",
+		},
+		{
+			body: func() *Multipart {
+				m := NewMultipart()
+				err := m.AddStruct(&form{
+					Title:  "struct",
+					File:   path,
+					Ignore: "ignore",
+					Avatar: &MultipartPart{
+						FileName:    "avatar.png",
+						ContentType: "image/png",
+						Reader:      strings.NewReader("png"),
+					},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return m
+			},
+			want: "struct|a.txt:text/plain; charset=utf-8:from path|avatar.png:image/png:png",
+		},
+	}
+
+	client := NewClient(WithEndpoint(server.URL))
+	for i, v := range tests {
+		response, err := client.Invoke(context.Background(), http.MethodPost, "/upload", v.body(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(response.Body)
+		_ = response.Body.Close()
+		if string(b) != v.want {
+			t.Errorf("index: %d, Multipart failed: target=%s want=%s", i, b, v.want)
+		}
+	}
+}
+
+func TestPipeReaderLazy(t *testing.T) {
+	started := false
+	r := newPipeReader(func(w io.Writer) error {
+		started = true
+		_, err := io.WriteString(w, "body")
+		return err
+	})
+	if err := r.Close(); err != nil || started {
+		t.Fatalf("Close() before Read failed: started=%v err=%v", started, err)
+	}
+	if _, err := r.Read(make([]byte, 4)); err != io.ErrClosedPipe {
+		t.Errorf("Read() after Close failed: target=%v want=%v", err, io.ErrClosedPipe)
+	}
+
+	r = newPipeReader(func(w io.Writer) error {
+		_, err := io.WriteString(w, "body")
+		return err
+	})
+	b, err := io.ReadAll(r)
+	if err != nil || string(b) != "body" {
+		t.Errorf("Read() failed: target=%s err=%v want=body", b, err)
+	}
+}
+
+func TestMultipartGetBody(t *testing.T) {
+	m := NewMultipart().AddField("title", "hello")
+	body, _, err := newRawBody(m)
+	if err != nil || body.getBody == nil {
+		t.Fatalf("getBody failed: %v", err)
+	}
+	first, _ := io.ReadAll(body.reader)
+	rc, _ := body.getBody()
+	again, _ := io.ReadAll(rc)
+	if len(first) == 0 || string(first) != string(again) {
+		t.Errorf("getBody failed: target=%q want=%q", again, first)
+	}
+
+	// a Reader part is read once
+	body, _, _ = newRawBody(NewMultipart().AddReader("file", "a.txt", strings.NewReader("a")))
+	if body.getBody != nil {
+		t.Errorf("getBody failed: want nil for a Reader part")
+	}
+}
+
+func TestMultipartReaderFileName(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(path, []byte("a,b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	m := NewMultipart()
+	err = m.AddStruct(&struct {
+		File   *os.File  `form:"file"`
+		Reader io.Reader `form:"reader"`
+	}{File: f, Reader: strings.NewReader("data")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts := m.Parts()
+	want := []string{`form-data; name="file"; filename="report.csv"`, `form-data; name="reader"`}
+	for i, part := range parts {
+		if target := part.header().Get("Content-Disposition"); target != want[i] {
+			t.Errorf("index: %d, Content-Disposition failed: target=%s want=%s", i, target, want[i])
+		}
+	}
+	if target := parts[0].header().Get("Content-Type"); target != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type failed: target=%s want=text/csv; charset=utf-8", target)
+	}
+}
@@ -0,0 +1,91 @@
+package ghttp
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// WithAcceptNegotiation generate Accept from the registered codecs if it is not set by the caller,
+// the content type of the client is preferred and the others have q=0.9. If the server responds
+// 406 Not Acceptable, the request is sent again with Accept of the content type of the client,
+// and then */*. A request body without GetBody is not sent again.
+func WithAcceptNegotiation(enabled bool) ClientOption {
+	return func(c *clientOptions) {
+		c.acceptNegotiation = enabled
+	}
+}
+
+// acceptHeader returns Accept of the media types which have a codec registered.
+func (c *Client) acceptHeader() string {
+	preferred := ""
+	if m, err := ParseMediaType(c.opts.contentType); err == nil {
+		preferred = m.FullType()
+	}
+	types := make([]string, 0)
+	for mediaType := range c.codecs.acceptTypes() {
+		if mediaType != preferred {
+			types = append(types, mediaType)
+		}
+	}
+	sort.Strings(types)
+
+	values := make([]string, 0, len(types)+1)
+	if preferred != "" {
+		values = append(values, preferred)
+	}
+	for _, mediaType := range types {
+		values = append(values, mediaType+";q=0.9")
+	}
+	return strings.Join(values, ", ")
+}
+
+// acceptFallbacks returns Accept values sent after 406 Not Acceptable.
+func (c *Client) acceptFallbacks() []string {
+	var fallbacks []string
+	if m, err := ParseMediaType(c.opts.contentType); err == nil {
+		fallbacks = append(fallbacks, m.FullType())
+	}
+	return append(fallbacks, "*/*")
+}
+
+// retryNotAcceptable sends req again with the fallback Accept values while the response is 406 Not Acceptable,
+// it returns the request of the final response.
+func (c *Client) retryNotAcceptable(req *http.Request, response *http.Response, reporters []progressReporter) (*http.Request, *http.Response, error) {
+	for _, accept := range c.acceptFallbacks() {
+		if response.StatusCode != http.StatusNotAcceptable {
+			break
+		}
+		if accept == req.Header.Get("Accept") {
+			continue
+		}
+		retry := req.Clone(req.Context())
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				break
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				_ = response.Body.Close()
+				return nil, nil, err
+			}
+			retry.Body = body
+		}
+		retry.Header.Set("Accept", accept)
+
+		_, _ = io.CopyN(io.Discard, response.Body, maxDrainSize)
+		_ = response.Body.Close()
+
+		sendReq := retry
+		if len(reporters) > 0 {
+			sendReq = trackUpload(retry, reporters)
+		}
+		var err error
+		if response, err = c.hc.Do(sendReq); err != nil {
+			return nil, nil, err
+		}
+		req = retry
+	}
+	return req, response, nil
+}
@@ -0,0 +1,101 @@
+package ghttp
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ProblemMediaType is the media type of ProblemDetails.
+const ProblemMediaType = "application/problem+json"
+
+// ProblemDetails is the error response body defined by RFC 9457 (obsoletes RFC 7807).
+// It is decoded from an application/problem+json response if WithNot2xxError is not set.
+type ProblemDetails struct {
+	// Type is a URI reference that identifies the problem type, default "about:blank".
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Extensions are the members not defined above.
+	Extensions map[string]any `json:"-"`
+}
+
+// problemMembers are the members of ProblemDetails, not stored in Extensions.
+var problemMembers = [...]string{"type", "title", "status", "detail", "instance"}
+
+func (p *ProblemDetails) String() string {
+	var buf strings.Builder
+	if p.Status > 0 {
+		buf.WriteString(strconv.Itoa(p.Status))
+		buf.WriteByte(' ')
+	}
+	switch {
+	case p.Title != "":
+		buf.WriteString(p.Title)
+	case p.Type != "":
+		buf.WriteString(p.Type)
+	}
+	if p.Detail != "" {
+		buf.WriteString(": ")
+		buf.WriteString(p.Detail)
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+func (p *ProblemDetails) UnmarshalJSON(data []byte) error {
+	type problem ProblemDetails
+	var v problem
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	var members map[string]any
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	for _, name := range problemMembers {
+		delete(members, name)
+	}
+	if len(members) > 0 {
+		v.Extensions = members
+	}
+	if v.Type == "" {
+		v.Type = "about:blank"
+	}
+	*p = ProblemDetails(v)
+	return nil
+}
+
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	type problem ProblemDetails
+	data, err := json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+	members := make(map[string]any, len(p.Extensions)+len(problemMembers))
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+	if err = json.Unmarshal(data, &members); err != nil {
+		return nil, err
+	}
+	return json.Marshal(members)
+}
+
+// ConvertToProblemDetails returns the ProblemDetails of an HTTPNot2xxError.
+func ConvertToProblemDetails(err error) (*ProblemDetails, bool) {
+	e, ok := ConvertToHTTPNot2xxError(err)
+	if !ok {
+		return nil, false
+	}
+	p, ok := e.Err.(*ProblemDetails)
+	return p, ok && p != nil
+}
+
+func isProblemResponse(response *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	return mediaType == ProblemMediaType
+}
@@ -0,0 +1,93 @@
+package ghttp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestProblemDetails(t *testing.T) {
+	body := `{"type":"https://example.com/probs/out-of-credit","title":"You do not have enough credit.",` +
+		`"status":403,"detail":"Your current balance is 30, but that costs 50.","instance":"/account/12345/msgs/abc",` +
+		`"balance":30,"accounts":["/account/12345","/account/67890"]}`
+
+	tests := []struct {
+		contentType string
+		body        string
+		problem     bool
+	}{
+		{
+			contentType: "application/problem+json; charset=utf-8",
+			body:        body,
+			problem:     true,
+		},
+		{
+			contentType: "application/json",
+			body:        body,
+			problem:     false,
+		},
+	}
+
+	for i, v := range tests {
+		client := NewClient(
+			WithEndpoint("http://example.com"),
+			WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusForbidden,
+					Header:     http.Header{"Content-Type": []string{v.contentType}},
+					Body:       io.NopCloser(strings.NewReader(v.body)),
+					Request:    req,
+				}, nil
+			})),
+		)
+		_, err := client.Invoke(context.Background(), http.MethodPost, "/account/12345/msgs", nil, nil)
+		if !v.problem {
+			if err != nil {
+				t.Errorf("index: %d, Invoke() failed: %s", i, err)
+			}
+			continue
+		}
+
+		e, ok := ConvertToHTTPNot2xxError(err)
+		if !ok {
+			t.Fatalf("index: %d, ConvertToHTTPNot2xxError() failed: %v", i, err)
+		}
+		problem, ok := e.Err.(*ProblemDetails)
+		if !ok {
+			t.Fatalf("index: %d, Err failed: target=%T want=*ProblemDetails", i, e.Err)
+		}
+		if problem.Status != 403 || problem.Instance != "/account/12345/msgs/abc" ||
+			problem.Extensions["balance"] != float64(30) || len(problem.Extensions) != 2 {
+			t.Errorf("index: %d, ProblemDetails failed: %+v", i, problem)
+		}
+		want := "403 You do not have enough credit.: Your current balance is 30, but that costs 50."
+		if problem.String() != want {
+			t.Errorf("index: %d, String() failed: target=%s want=%s", i, problem.String(), want)
+		}
+		if p, ok := ConvertToProblemDetails(err); !ok || p != problem {
+			t.Errorf("index: %d, ConvertToProblemDetails() failed", i)
+		}
+	}
+}
+
+func TestProblemDetailsJSON(t *testing.T) {
+	var problem ProblemDetails
+	if err := json.Unmarshal([]byte(`{"title":"Not Found","status":404,"code":"E404"}`), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Type != "about:blank" || problem.Extensions["code"] != "E404" {
+		t.Errorf("UnmarshalJSON() failed: %+v", problem)
+	}
+
+	b, err := json.Marshal(problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"code":"E404","status":404,"title":"Not Found","type":"about:blank"}`
+	if string(b) != want {
+		t.Errorf("MarshalJSON() failed: target=%s want=%s", b, want)
+	}
+}
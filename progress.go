@@ -0,0 +1,182 @@
+package ghttp
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultProgressInterval is the minimum interval between two progress reports.
+const DefaultProgressInterval = 200 * time.Millisecond
+
+type ProgressDirection int
+
+const (
+	// ProgressUpload is the progress of the request body.
+	ProgressUpload ProgressDirection = iota
+	// ProgressDownload is the progress of the response body.
+	ProgressDownload
+)
+
+func (d ProgressDirection) String() string {
+	if d == ProgressUpload {
+		return "upload"
+	}
+	return "download"
+}
+
+// Progress is the transfer progress of a request or response body.
+type Progress struct {
+	Direction ProgressDirection
+	// Transferred is the number of bytes transferred in the current attempt.
+	Transferred int64
+	// Total is the size of the body, -1 if unknown.
+	Total int64
+	// Rate is the average bytes per second.
+	Rate float64
+	// ETA is the estimated remaining time, 0 if unknown.
+	ETA     time.Duration
+	Elapsed time.Duration
+	// Done reports the body is completely transferred.
+	Done bool
+}
+
+// ProgressFunc is called at most once per interval, and always when the body is done.
+type ProgressFunc func(p Progress)
+
+// progressOption is implemented by the CallOption that reports progress.
+type progressOption interface {
+	progress() (ProgressFunc, time.Duration)
+}
+
+type progressReporter struct {
+	fn       ProgressFunc
+	interval time.Duration
+}
+
+func newProgressReporter(fn ProgressFunc, interval time.Duration) progressReporter {
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+	return progressReporter{fn: fn, interval: interval}
+}
+
+func (c *Client) progressReporters(opts []CallOption) []progressReporter {
+	var reporters []progressReporter
+	if c.opts.progress != nil {
+		reporters = append(reporters, newProgressReporter(c.opts.progress, c.opts.progressInterval))
+	}
+	for _, opt := range opts {
+		if po, ok := opt.(progressOption); ok {
+			if fn, interval := po.progress(); fn != nil {
+				reporters = append(reporters, newProgressReporter(fn, interval))
+			}
+		}
+	}
+	return reporters
+}
+
+// trackUpload returns a shallow copy of req whose body reports progress,
+// the progress is reset when the body is re-read by GetBody.
+func trackUpload(req *http.Request, reporters []progressReporter) *http.Request {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req
+	}
+	total := req.ContentLength
+	if total <= 0 {
+		total = -1
+	}
+
+	r := *req
+	r.Body = newProgressBody(req.Body, ProgressUpload, total, reporters)
+	if getBody := req.GetBody; getBody != nil {
+		r.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return newProgressBody(body, ProgressUpload, total, reporters), nil
+		}
+	}
+	return &r
+}
+
+func trackDownload(response *http.Response, reporters []progressReporter) {
+	if response.Body == nil || response.Body == http.NoBody {
+		return
+	}
+	response.Body = newProgressBody(response.Body, ProgressDownload, response.ContentLength, reporters)
+}
+
+type progressBody struct {
+	rc        io.ReadCloser
+	direction ProgressDirection
+	total     int64
+	reporters []progressReporter
+
+	mu          sync.Mutex
+	start       time.Time
+	last        []time.Time
+	transferred int64
+	done        bool
+}
+
+func newProgressBody(rc io.ReadCloser, direction ProgressDirection, total int64, reporters []progressReporter) *progressBody {
+	return &progressBody{
+		rc:        rc,
+		direction: direction,
+		total:     total,
+		reporters: reporters,
+		start:     time.Now(),
+		last:      make([]time.Time, len(reporters)),
+	}
+}
+
+func (p *progressBody) Read(b []byte) (int, error) {
+	n, err := p.rc.Read(b)
+	p.add(n, err == io.EOF)
+	return n, err
+}
+
+func (p *progressBody) Close() error {
+	return p.rc.Close()
+}
+
+func (p *progressBody) unwrap() io.ReadCloser {
+	return p.rc
+}
+
+func (p *progressBody) add(n int, eof bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.done {
+		return
+	}
+	p.transferred += int64(n)
+	p.done = eof || (p.total > 0 && p.transferred >= p.total)
+
+	now := time.Now()
+	elapsed := now.Sub(p.start)
+	progress := Progress{
+		Direction:   p.direction,
+		Transferred: p.transferred,
+		Total:       p.total,
+		Elapsed:     elapsed,
+		Done:        p.done,
+	}
+	if elapsed > 0 {
+		progress.Rate = float64(p.transferred) / elapsed.Seconds()
+	}
+	if p.total > 0 && progress.Rate > 0 {
+		progress.ETA = time.Duration(float64(p.total-p.transferred) / progress.Rate * float64(time.Second))
+	}
+
+	for i, reporter := range p.reporters {
+		if !p.done && now.Sub(p.last[i]) < reporter.interval {
+			continue
+		}
+		p.last[i] = now
+		reporter.fn(progress)
+	}
+}
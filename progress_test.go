@@ -0,0 +1,81 @@
+package ghttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProgress(t *testing.T) {
+	body := strings.Repeat("a", 1000)
+
+	var clientReports, callReports []Progress
+	client := NewClient(
+		WithEndpoint("http://example.com"),
+		WithProgress(func(p Progress) {
+			clientReports = append(clientReports, p)
+		}, time.Hour),
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			// the first attempt fails after 10 bytes, the body is re-read by GetBody
+			_, _ = io.ReadFull(req.Body, make([]byte, 10))
+			rc, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			_, _ = io.Copy(io.Discard, rc)
+			return &http.Response{
+				StatusCode:    http.StatusOK,
+				Header:        http.Header{"Content-Type": []string{"text/plain"}},
+				Body:          io.NopCloser(bytes.NewReader([]byte(body))),
+				ContentLength: int64(len(body)),
+				Request:       req,
+			}, nil
+		})),
+	)
+
+	response, err := client.Invoke(context.Background(), http.MethodPost, "/upload", []byte(body), nil, &CallOptions{
+		Progress: func(p Progress) {
+			callReports = append(callReports, p)
+		},
+		ProgressInterval: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = io.Copy(io.Discard, response.Body)
+	_ = response.Body.Close()
+
+	var uploads, downloads []int64
+	for _, p := range callReports {
+		if p.Direction == ProgressUpload {
+			uploads = append(uploads, p.Transferred)
+		} else {
+			downloads = append(downloads, p.Transferred)
+		}
+	}
+	if len(uploads) < 2 || uploads[0] != 10 || uploads[len(uploads)-1] != 1000 || uploads[1] > 1000 {
+		t.Errorf("upload progress failed: %v", uploads)
+	}
+	if len(downloads) == 0 || downloads[len(downloads)-1] != 1000 {
+		t.Errorf("download progress failed: %v", downloads)
+	}
+	last := callReports[len(callReports)-1]
+	if !last.Done || last.Total != 1000 {
+		t.Errorf("progress done failed: %+v", last)
+	}
+
+	// throttled, only the first report and the done reports
+	var done int
+	for _, p := range clientReports {
+		if p.Done {
+			done++
+		}
+	}
+	if done != 2 || len(clientReports) > 5 {
+		t.Errorf("throttled progress failed: %+v", clientReports)
+	}
+}
@@ -0,0 +1,205 @@
+package ghttp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	stdencoding "encoding"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/zdz1715/ghttp/encoding"
+	"github.com/zdz1715/ghttp/encoding/binary"
+	"github.com/zdz1715/ghttp/encoding/text"
+)
+
+// sniffLen is the number of leading bytes of the body used by SniffContentType.
+const sniffLen = 512
+
+// WithBodySniffing detect the format of the response body from its leading bytes if Content-Type is
+// missing, not registered, or generic (text/plain, application/octet-stream), and bind it with the codec
+// of the detected media type. The detected media type is reported by Debug. Replies of *string, *[]byte,
+// io.Writer, encoding.TextUnmarshaler and encoding.BinaryUnmarshaler are bound as labeled.
+func WithBodySniffing(enabled bool) ClientOption {
+	return func(c *clientOptions) {
+		c.bodySniffing = enabled
+	}
+}
+
+var (
+	htmlPrefixes = [][]byte{[]byte("<!doctype html"), []byte("<html"), []byte("<head"), []byte("<body")}
+	yamlKeyLine  = regexp.MustCompile(`^(- |-$|["']?[A-Za-z_][\w.\- ]*["']?\s*:(\s|$))`)
+)
+
+// SniffContentType returns the media type of data detected from its leading bytes:
+// application/json, application/xml, text/html, application/yaml or application/x-protobuf,
+// "" if it is not detected. Like http.DetectContentType it is a heuristic, e.g. a plain text
+// line "key: value" is detected as YAML.
+func SniffContentType(data []byte) string {
+	if len(data) > sniffLen {
+		data = data[:sniffLen]
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) == 0 {
+		return ""
+	}
+
+	switch trimmed[0] {
+	case '{', '[':
+		return "application/json"
+	case '<':
+		lower := bytes.ToLower(trimmed)
+		for _, prefix := range htmlPrefixes {
+			if bytes.HasPrefix(lower, prefix) {
+				return "text/html"
+			}
+		}
+		return "application/xml"
+	}
+
+	if isText(data) {
+		if isYAML(trimmed) {
+			return "application/yaml"
+		}
+		return ""
+	}
+	if isProtobuf(data) {
+		return "application/x-protobuf"
+	}
+	return ""
+}
+
+// isText reports whether data is UTF-8 without control characters, the last rune can be truncated.
+func isText(data []byte) bool {
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		if r == utf8.RuneError && size == 1 {
+			return len(data) < utf8.UTFMax && !utf8.FullRune(data)
+		}
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return false
+		}
+		data = data[size:]
+	}
+	return true
+}
+
+// isYAML reports whether the first line which is not a comment starts a document, a mapping or a sequence.
+func isYAML(data []byte) bool {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimRight(line, " \t\r")
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		if bytes.HasPrefix(line, []byte("---")) || bytes.HasPrefix(line, []byte("%YAML")) {
+			return true
+		}
+		return yamlKeyLine.Match(line)
+	}
+	return false
+}
+
+// isProtobuf reports whether data is a sequence of valid protobuf fields, the last field can be truncated.
+func isProtobuf(data []byte) bool {
+	fields := 0
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return fields > 0 && protowire.ParseError(n) == io.ErrUnexpectedEOF
+		}
+		if num > protowire.MaxValidNumber || typ == protowire.StartGroupType || typ == protowire.EndGroupType {
+			return false
+		}
+		m := protowire.ConsumeFieldValue(num, typ, data[n:])
+		if m < 0 {
+			return protowire.ParseError(m) == io.ErrUnexpectedEOF
+		}
+		data = data[n+m:]
+		fields++
+	}
+	return fields > 0
+}
+
+type sniffKey struct{}
+
+// sniffedContentType returns the media type sniffed by Do, ok is false if the body is not sniffed.
+func sniffedContentType(response *http.Response) (string, bool) {
+	if response.Request == nil {
+		return "", false
+	}
+	mediaType, ok := response.Request.Context().Value(sniffKey{}).(string)
+	return mediaType, ok
+}
+
+// sniffResponse detects the format of the response body, the leading bytes are kept in the body.
+func (c *Client) sniffResponse(response *http.Response) {
+	if !c.opts.bodySniffing || response.Request == nil || response.Body == nil || response.Body == http.NoBody ||
+		response.Request.Method == http.MethodHead || response.StatusCode == http.StatusNoContent ||
+		response.StatusCode == http.StatusNotModified || isStreamingContext(response.Request.Context()) {
+		return
+	}
+	if contentType := response.Header.Get("Content-Type"); contentType != "" {
+		codec := c.codecs.GetCodecByContentType(contentType)
+		if codec != nil && codec.Name() != text.Name && codec.Name() != binary.Name {
+			return
+		}
+	}
+
+	body := &sniffBody{r: bufio.NewReaderSize(response.Body, sniffLen), rc: response.Body}
+	response.Body = body
+	data, err := body.r.Peek(sniffLen)
+	if len(data) == 0 || (err != nil && err != io.EOF && err != bufio.ErrBufferFull) {
+		return
+	}
+	ctx := context.WithValue(response.Request.Context(), sniffKey{}, SniffContentType(data))
+	response.Request = response.Request.WithContext(ctx)
+}
+
+// sniffedCodec returns the codec of the sniffed media type to bind reply, or a descriptive error.
+func (c *Client) sniffedCodec(response *http.Response, mediaType string, reply any) (encoding.Codec, error) {
+	contentType := response.Header.Get("Content-Type")
+	switch mediaType {
+	case "":
+		return nil, fmt.Errorf("response: can not detect the format of the body (Content-Type: %q)", contentType)
+	case "text/html":
+		return nil, fmt.Errorf("response: the body is text/html (Content-Type: %q), can not bind to %T", contentType, reply)
+	}
+	codec := c.codecs.GetCodecByContentType(mediaType)
+	if codec == nil {
+		return nil, fmt.Errorf("response: no codec for the sniffed content type: %s", mediaType)
+	}
+	return codec, nil
+}
+
+// isRawReply reports whether reply takes the body as is.
+func isRawReply(reply any) bool {
+	switch reply.(type) {
+	case *string, *[]byte, io.Writer, stdencoding.TextUnmarshaler, stdencoding.BinaryUnmarshaler:
+		return true
+	}
+	return false
+}
+
+// sniffBody reads the leading bytes peeked by sniffResponse and then the rest of the body.
+type sniffBody struct {
+	r  *bufio.Reader
+	rc io.ReadCloser
+}
+
+func (b *sniffBody) Read(p []byte) (int, error) {
+	return b.r.Read(p)
+}
+
+func (b *sniffBody) Close() error {
+	return b.rc.Close()
+}
+
+func (b *sniffBody) unwrap() io.ReadCloser {
+	return b.rc
+}
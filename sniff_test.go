@@ -0,0 +1,114 @@
+package ghttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestSniffContentType(t *testing.T) {
+	pb, _ := proto.Marshal(wrapperspb.String("ghttp"))
+	tests := []struct {
+		data string
+		want string
+	}{
+		{data: ` {"name":"ghttp"}`, want: "application/json"},
+		{data: "\xef\xbb\xbf[1,2]", want: "application/json"},
+		{data: `<?xml version="1.0"?><name>ghttp</name>`, want: "application/xml"},
+		{data: "\n<!DOCTYPE html><html></html>", want: "text/html"},
+		{data: "<HTML><body>502 Bad Gateway</body></HTML>", want: "text/html"},
+		{data: "# comment\nname: ghttp\n", want: "application/yaml"},
+		{data: "---\n- ghttp\n", want: "application/yaml"},
+		{data: string(pb), want: "application/x-protobuf"},
+		{data: "502 Bad Gateway", want: ""},
+		{data: "\x00\x01\x02", want: ""},
+		{data: "", want: ""},
+	}
+
+	for i, v := range tests {
+		target := SniffContentType([]byte(v.data))
+		if target != v.want {
+			t.Errorf("index: %d, SniffContentType() failed: target=%s want=%s", i, target, v.want)
+		}
+	}
+}
+
+func TestBodySniffing(t *testing.T) {
+	var debug bytes.Buffer
+	newClient := func(contentType, body string) *Client {
+		return NewClient(
+			WithEndpoint("http://example.com"),
+			WithBodySniffing(true),
+			WithDebug(func() DebugInterface {
+				return &Debug{Writer: &debug}
+			}),
+			WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				header := http.Header{}
+				if contentType != "" {
+					header.Set("Content-Type", contentType)
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     header,
+					Body:       io.NopCloser(strings.NewReader(body)),
+					Request:    req,
+				}, nil
+			})),
+		)
+	}
+
+	type reply struct {
+		Name string `json:"name" xml:"name" yaml:"name"`
+	}
+	tests := []struct {
+		contentType string
+		body        string
+		err         string
+	}{
+		{contentType: "text/plain", body: `{"name":"ghttp"}`},
+		{contentType: "", body: "name: ghttp\n"},
+		{contentType: "application/octet-stream", body: "<reply><name>ghttp</name></reply>"},
+		{contentType: "application/x-unknown", body: `{"name":"ghttp"}`},
+		{contentType: "text/plain", body: "<html><body>login</body></html>", err: "the body is text/html"},
+		{contentType: "", body: "upstream error", err: "can not detect the format of the body"},
+		{contentType: "text/plain", body: `{"name":`, err: "decode the body sniffed as application/json"},
+	}
+
+	for i, v := range tests {
+		debug.Reset()
+		var target reply
+		_, err := newClient(v.contentType, v.body).Invoke(context.Background(), http.MethodGet, "/", nil, &target)
+		if v.err != "" {
+			if err == nil || !strings.Contains(err.Error(), v.err) {
+				t.Errorf("index: %d, Invoke() failed: err=%v want=%s", i, err, v.err)
+			}
+			continue
+		}
+		if err != nil || target.Name != "ghttp" {
+			t.Errorf("index: %d, Invoke() failed: target=%+v err=%v", i, target, err)
+		}
+		if !strings.Contains(debug.String(), "* response body sniffed: ") {
+			t.Errorf("index: %d, Debug failed: %s", i, debug.String())
+		}
+	}
+
+	// the body of a raw reply is bound as labeled
+	var raw string
+	_, err := newClient("text/plain", `{"name":"ghttp"}`).Invoke(context.Background(), http.MethodGet, "/", nil, &raw)
+	if err != nil || raw != `{"name":"ghttp"}` {
+		t.Errorf("raw reply failed: target=%s err=%v", raw, err)
+	}
+
+	// a registered Content-Type is not sniffed
+	var target reply
+	_, err = newClient("application/xml", `{"name":"ghttp"}`).Invoke(context.Background(), http.MethodGet, "/", nil, &target)
+	if err == nil {
+		t.Errorf("registered Content-Type failed: target=%+v", target)
+	}
+}
@@ -0,0 +1,279 @@
+package ghttp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zdz1715/ghttp/encoding"
+	"github.com/zdz1715/ghttp/encoding/json"
+)
+
+const (
+	// defaultSSERetry is the reconnection time if the server does not send a retry field.
+	defaultSSERetry = 3 * time.Second
+	// maxSSELineSize is the maximum size of a line in the event stream.
+	maxSSELineSize = 1 << 20
+)
+
+// Event is a Server-Sent Event, see https://html.spec.whatwg.org/multipage/server-sent-events.html
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+	Retry time.Duration
+
+	codec encoding.Codec
+}
+
+// Unmarshal decodes Data with the codec of SSEOptions.DataContentType, json is used by default.
+func (e *Event) Unmarshal(v any) error {
+	codec := e.codec
+	if codec == nil {
+		codec = encoding.GetCodec(json.Name)
+	}
+	return codec.Unmarshal([]byte(e.Data), v)
+}
+
+// SSEOptions configure Client.Subscribe.
+type SSEOptions struct {
+	// Method defaults to GET.
+	Method string
+	// Args is the request body, it is marshaled like the args of Client.Invoke and sent again on reconnect.
+	Args any
+	// LastEventID is sent as the Last-Event-ID header of the first request.
+	LastEventID string
+	// Retry is the reconnection time until the server sends a retry field, default 3s.
+	Retry time.Duration
+	// MaxReconnects is the maximum number of consecutive reconnects, 0 is unlimited and < 0 disables reconnecting.
+	MaxReconnects int
+	// DataContentType selects the codec of Event.Unmarshal, e.g. application/json.
+	DataContentType string
+	// CallOptions are applied to every request.
+	CallOptions []CallOption
+}
+
+type eventStream struct {
+	client      *Client
+	path        string
+	opts        SSEOptions
+	lastEventID string
+	retry       time.Duration
+	codec       encoding.Codec
+	fn          func(*Event) error
+}
+
+// Subscribe consumes a text/event-stream endpoint and calls fn for every event. The connection is
+// re-established after it is lost, using the Last-Event-ID header and the retry hint of the server.
+// The client timeout is not applied, the stream ends when ctx is done, fn returns an error,
+// the server responds with 204 No Content or a non-2xx status code.
+// ErrStopStream returned by fn stops the stream without error.
+func (c *Client) Subscribe(ctx context.Context, path string, opts *SSEOptions, fn func(*Event) error) error {
+	s := &eventStream{
+		client: c,
+		path:   path,
+		fn:     fn,
+		retry:  defaultSSERetry,
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.Method == "" {
+		s.opts.Method = http.MethodGet
+	}
+	if s.opts.Retry > 0 {
+		s.retry = s.opts.Retry
+	}
+	if s.opts.DataContentType != "" {
+		s.codec = c.codecs.GetCodecByContentType(s.opts.DataContentType)
+	} else {
+		s.codec = c.codecs.GetCodec(json.Name)
+	}
+	s.lastEventID = s.opts.LastEventID
+
+	var reconnects int
+	for {
+		received, reconnect, err := s.connect(ctx)
+		if errors.Is(err, ErrStopStream) {
+			return nil
+		}
+		if !reconnect {
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		if received {
+			reconnects = 0
+		}
+		reconnects++
+		if s.opts.MaxReconnects < 0 || (s.opts.MaxReconnects > 0 && reconnects > s.opts.MaxReconnects) {
+			return err
+		}
+
+		timer := time.NewTimer(s.retry)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// connect reads the events of one connection, reconnect reports whether the connection was lost.
+func (s *eventStream) connect(ctx context.Context) (received bool, reconnect bool, err error) {
+	req, err := s.client.newRequest(streamingContext(ctx), s.opts.Method, s.path, s.opts.Args)
+	if err != nil {
+		return false, false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if s.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", s.lastEventID)
+	}
+
+	response, err := s.client.Do(req, s.opts.CallOptions...)
+	if err != nil {
+		// only network errors are retried
+		return false, isTransportError(ctx, err), err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNoContent {
+		return false, false, nil
+	}
+	if Not2xxCode(response.StatusCode) {
+		return false, false, &HTTPNot2xxError{
+			URL:        response.Request.URL,
+			Method:     response.Request.Method,
+			StatusCode: response.StatusCode,
+		}
+	}
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	if mediaType != "text/event-stream" {
+		return false, false, fmt.Errorf("sse: unsupported content type: %s", response.Header.Get("Content-Type"))
+	}
+
+	return s.read(response.Body)
+}
+
+// isTransportError reports whether err of Client.Do is returned by sending the request, not by
+// a CallOption, the URL or the not 2xx response, and is not caused by ctx.
+func isTransportError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && urlErr.Op != "parse"
+}
+
+// read parses the event stream until the connection is closed, received reports whether any event was dispatched.
+// It returns the error of fn, or lost is true with the read error of the connection.
+func (s *eventStream) read(r io.Reader) (received bool, lost bool, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxSSELineSize)
+	scanner.Split(scanSSELines)
+
+	var (
+		data      strings.Builder
+		eventType string
+		id        = s.lastEventID
+		first     = true
+	)
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if first {
+			line = bytes.TrimPrefix(line, []byte("\xEF\xBB\xBF"))
+			first = false
+		}
+
+		// dispatch the event
+		if len(line) == 0 {
+			s.lastEventID = id
+			if data.Len() == 0 {
+				eventType = ""
+				continue
+			}
+			if eventType == "" {
+				eventType = "message"
+			}
+			event := &Event{
+				ID:    s.lastEventID,
+				Event: eventType,
+				Data:  strings.TrimSuffix(data.String(), "\n"),
+				Retry: s.retry,
+				codec: s.codec,
+			}
+			data.Reset()
+			eventType = ""
+			received = true
+			if err = s.fn(event); err != nil {
+				return received, false, err
+			}
+			continue
+		}
+
+		// comment
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+
+		switch string(field) {
+		case "event":
+			eventType = string(value)
+		case "data":
+			data.Write(value)
+			data.WriteByte('\n')
+		case "id":
+			if bytes.IndexByte(value, 0) == -1 {
+				id = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(string(value), 10, 32); err == nil {
+				s.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+
+	// the incomplete event is discarded
+	return received, true, scanner.Err()
+}
+
+// scanSSELines is a bufio.SplitFunc, lines end with CRLF, LF or CR.
+func scanSSELines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// CR at the end of the buffer may be followed by LF
+		if i+1 == len(data) && !atEOF {
+			return 0, nil, nil
+		}
+		if i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,126 @@
+package ghttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	var connections int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch atomic.AddInt32(&connections, 1) {
+		case 1:
+			_, _ = fmt.Fprint(w, "\xEF\xBB\xBF: comment\r\nretry: 1\r\n\r\nid: 1\ndata: {\"n\":1}\n\n")
+			_, _ = fmt.Fprint(w, "event: update\rid: 2\rdata: line1\rdata:line2\r\r")
+			// incomplete event is discarded
+			_, _ = fmt.Fprint(w, "id: 3\ndata: lost")
+		case 2:
+			if id := r.Header.Get("Last-Event-ID"); id != "2" {
+				http.Error(w, "Last-Event-ID: "+id, http.StatusBadRequest)
+				return
+			}
+			_, _ = fmt.Fprint(w, "id: 3\ndata: {\"n\":3}\n\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(WithEndpoint(server.URL))
+
+	var events []string
+	err := client.Subscribe(context.Background(), "/events", nil, func(event *Event) error {
+		events = append(events, fmt.Sprintf("%s|%s|%s|%s", event.ID, event.Event, event.Data, event.Retry))
+		if event.Event == "message" {
+			var data struct {
+				N int `json:"n"`
+			}
+			if err := event.Unmarshal(&data); err != nil {
+				return err
+			}
+			if data.N == 0 {
+				return fmt.Errorf("Event.Unmarshal() failed: %s", event.Data)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`1|message|{"n":1}|1ms`,
+		"2|update|line1\nline2|1ms",
+		`3|message|{"n":3}|1ms`,
+	}
+	if strings.Join(events, ",") != strings.Join(want, ",") {
+		t.Errorf("Subscribe() failed: target=%q want=%q", events, want)
+	}
+}
+
+func TestSubscribeStop(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < 3; i++ {
+			_, _ = fmt.Fprintf(w, "data: %d\n\n", i)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(WithEndpoint(server.URL))
+
+	var count int
+	err := client.Subscribe(context.Background(), "/events", &SSEOptions{MaxReconnects: -1}, func(event *Event) error {
+		count++
+		if event.Data == "1" {
+			return ErrStopStream
+		}
+		return nil
+	})
+	if err != nil || count != 2 {
+		t.Errorf("Subscribe() failed: count=%d err=%v", count, err)
+	}
+}
+
+func TestSubscribeNotRetried(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithEndpoint(server.URL))
+
+	var calls int
+	hookErr := errors.New("no token")
+	err := client.Subscribe(context.Background(), "/events", &SSEOptions{
+		CallOptions: []CallOption{&CallOptions{
+			BeforeHook: func(request *http.Request) error {
+				calls++
+				return hookErr
+			},
+		}},
+	}, func(event *Event) error {
+		return nil
+	})
+	if !errors.Is(err, hookErr) || calls != 1 || atomic.LoadInt32(&requests) != 0 {
+		t.Errorf("Subscribe() failed: calls=%d requests=%d err=%v", calls, requests, err)
+	}
+
+	// network errors are retried
+	server.Close()
+	err = client.Subscribe(context.Background(), "/events", &SSEOptions{MaxReconnects: 2, Retry: time.Millisecond}, func(event *Event) error {
+		return nil
+	})
+	if !isTransportError(context.Background(), err) {
+		t.Errorf("Subscribe() failed: want transport error, got %v", err)
+	}
+}
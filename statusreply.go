@@ -0,0 +1,66 @@
+package ghttp
+
+// StatusReplies is the reply of Invoke which binds the response body to the target registered
+// for the status code, e.g. the resource for 200 and the async job for 202. Exact status codes
+// are preferred to ranges, and ranges are matched in the order registered. The body is drained and
+// closed if no target is registered for the status code. Non-2xx responses are returned as errors
+// only if WithNot2xxError is set (or the body is application/problem+json), otherwise they are
+// matched like any other status code.
+type StatusReplies struct {
+	codes  map[int]any
+	ranges []statusRange
+
+	statusCode int
+	target     any
+}
+
+type statusRange struct {
+	lo, hi int
+	target any
+}
+
+// NewStatusReplies returns StatusReplies without targets.
+func NewStatusReplies() *StatusReplies {
+	return &StatusReplies{codes: make(map[int]any)}
+}
+
+// On registers target for statusCode.
+func (s *StatusReplies) On(statusCode int, target any) *StatusReplies {
+	if s.codes == nil {
+		s.codes = make(map[int]any)
+	}
+	s.codes[statusCode] = target
+	return s
+}
+
+// OnRange registers target for the status codes from lo to hi inclusive, e.g. 200, 299.
+func (s *StatusReplies) OnRange(lo, hi int, target any) *StatusReplies {
+	s.ranges = append(s.ranges, statusRange{lo: lo, hi: hi, target: target})
+	return s
+}
+
+// StatusCode returns the status code of the last bound response.
+func (s *StatusReplies) StatusCode() int {
+	return s.statusCode
+}
+
+// Target returns the target filled by the last bound response, nil if no target is registered for it.
+func (s *StatusReplies) Target() any {
+	return s.target
+}
+
+// match records statusCode and returns its target.
+func (s *StatusReplies) match(statusCode int) any {
+	s.statusCode, s.target = statusCode, nil
+	if target, ok := s.codes[statusCode]; ok {
+		s.target = target
+		return target
+	}
+	for _, r := range s.ranges {
+		if statusCode >= r.lo && statusCode <= r.hi {
+			s.target = r.target
+			return r.target
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package ghttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStatusReplies(t *testing.T) {
+	type project struct {
+		Name string `json:"name"`
+	}
+	type job struct {
+		ID string `json:"id"`
+	}
+	type multiStatus struct {
+		Results []int `json:"results"`
+	}
+
+	tests := []struct {
+		statusCode int
+		body       string
+		want       string
+	}{
+		{statusCode: http.StatusOK, body: `{"name":"ghttp"}`, want: "project"},
+		{statusCode: http.StatusAccepted, body: `{"id":"1"}`, want: "job"},
+		{statusCode: http.StatusMultiStatus, body: `{"results":[200,404]}`, want: "multiStatus"},
+		{statusCode: http.StatusCreated, body: `{"name":"created"}`, want: "range"},
+		{statusCode: http.StatusNoContent, body: ``, want: ""},
+	}
+
+	for i, v := range tests {
+		client := NewClient(
+			WithEndpoint("http://example.com"),
+			WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: v.statusCode,
+					Header:     http.Header{"Content-Type": {"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(v.body)),
+					Request:    req,
+				}, nil
+			})),
+		)
+
+		var (
+			p, created project
+			j          job
+			m          multiStatus
+		)
+		replies := NewStatusReplies().
+			On(http.StatusOK, &p).
+			On(http.StatusAccepted, &j).
+			On(http.StatusMultiStatus, &m).
+			OnRange(200, 203, &created)
+		if _, err := client.Invoke(context.Background(), http.MethodPost, "/projects", nil, replies); err != nil {
+			t.Fatalf("index: %d, Invoke() failed: %v", i, err)
+		}
+		if replies.StatusCode() != v.statusCode {
+			t.Errorf("index: %d, StatusCode() failed: target=%d want=%d", i, replies.StatusCode(), v.statusCode)
+		}
+
+		var target string
+		switch replies.Target() {
+		case &p:
+			target = "project"
+			if p.Name != "ghttp" {
+				t.Errorf("index: %d, project failed: %+v", i, p)
+			}
+		case &j:
+			target = "job"
+			if j.ID != "1" {
+				t.Errorf("index: %d, job failed: %+v", i, j)
+			}
+		case &m:
+			target = "multiStatus"
+			if len(m.Results) != 2 {
+				t.Errorf("index: %d, multiStatus failed: %+v", i, m)
+			}
+		case &created:
+			target = "range"
+			if created.Name != "created" {
+				t.Errorf("index: %d, range failed: %+v", i, created)
+			}
+		}
+		if target != v.want {
+			t.Errorf("index: %d, Target() failed: target=%s want=%s", i, target, v.want)
+		}
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func TestStatusRepliesUnmatched(t *testing.T) {
+	body := &closeRecorder{Reader: strings.NewReader(`{"name":"ghttp"}`)}
+	client := NewClient(
+		WithEndpoint("http://example.com"),
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusCreated,
+				Header:     http.Header{"Content-Type": {"application/json"}},
+				Body:       body,
+				Request:    req,
+			}, nil
+		})),
+	)
+
+	replies := NewStatusReplies().On(http.StatusOK, &struct{}{})
+	if _, err := client.Invoke(context.Background(), http.MethodPost, "/projects", nil, replies); err != nil {
+		t.Fatal(err)
+	}
+	if replies.Target() != nil || replies.StatusCode() != http.StatusCreated {
+		t.Errorf("match failed: target=%v statusCode=%d", replies.Target(), replies.StatusCode())
+	}
+	if rest, _ := io.ReadAll(body.Reader); !body.closed || len(rest) != 0 {
+		t.Errorf("body failed: closed=%v rest=%s want drained and closed", body.closed, rest)
+	}
+}
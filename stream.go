@@ -0,0 +1,198 @@
+package ghttp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/zdz1715/ghttp/encoding"
+)
+
+// ErrStopStream can be returned by the callback of DecodeLines to stop reading without error.
+var ErrStopStream = errors.New("ghttp: stop stream")
+
+// ErrLineTooLong is returned by LineIterator if a line is longer than the maximum line size.
+var ErrLineTooLong = errors.New("stream: line too long")
+
+// DefaultMaxLineSize is the default maximum size of a line read by LineIterator, including the newline.
+const DefaultMaxLineSize = 1 << 20
+
+// streamMediaTypes are never read by Debug, the body is consumed by the caller.
+var streamMediaTypes = map[string]struct{}{
+	"application/x-ndjson":    {},
+	"application/ndjson":      {},
+	"application/jsonl":       {},
+	"application/x-jsonlines": {},
+	"application/json-seq":    {},
+	"application/stream+json": {},
+	"text/event-stream":       {},
+}
+
+func isStreamResponse(response *http.Response) bool {
+	if response.Request != nil && isStreamingContext(response.Request.Context()) {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(response.Header.Get("Content-Type"))
+	_, ok := streamMediaTypes[mediaType]
+	return ok
+}
+
+// LineIterator decodes a newline-delimited response body (NDJSON / JSON Lines) one line at a time.
+// Lines are read only when Next is called, so a slow consumer applies backpressure to the server.
+//
+//	it := ghttp.NewLineIterator[Event](response)
+//	defer it.Close()
+//	for it.Next() {
+//		event := it.Value()
+//	}
+//	if err := it.Err(); err != nil {}
+type LineIterator[T any] struct {
+	response *http.Response
+	reader   *bufio.Reader
+	codec    encoding.Codec
+	maxLine  int
+
+	value   T
+	err     error
+	pending error
+	done    bool
+}
+
+// NewLineIterator returns a LineIterator, each line is decoded with the codec of the response Content-Type
+// looked up from the codecs of the client which sent the request, json is used if it is not registered.
+func NewLineIterator[T any](response *http.Response) *LineIterator[T] {
+	codec, _ := codecsFromRequest(response.Request).CodecForResponse(response)
+	return &LineIterator[T]{
+		response: response,
+		reader:   bufio.NewReader(response.Body),
+		codec:    codec,
+		maxLine:  DefaultMaxLineSize,
+	}
+}
+
+// SetMaxLineSize sets the maximum size of a line including the newline, Next stops with ErrLineTooLong
+// if a line is longer. It must be called before the first Next, size <= 0 uses DefaultMaxLineSize.
+func (it *LineIterator[T]) SetMaxLineSize(size int) *LineIterator[T] {
+	if size <= 0 {
+		size = DefaultMaxLineSize
+	}
+	it.maxLine = size
+	return it
+}
+
+// Next decodes the next non-empty line, it returns false when the body is finished,
+// the request context is done or an error occurred. The body is closed when Next returns false.
+func (it *LineIterator[T]) Next() bool {
+	if it.done {
+		return false
+	}
+
+	if it.pending != nil {
+		it.finish(it.pending)
+		return false
+	}
+
+	for {
+		if it.response.Request != nil {
+			if err := it.response.Request.Context().Err(); err != nil {
+				it.finish(err)
+				return false
+			}
+		}
+
+		line, err := it.readLine()
+		if err == ErrLineTooLong {
+			it.finish(err)
+			return false
+		}
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			var value T
+			if uerr := it.codec.Unmarshal(line, &value); uerr != nil {
+				it.finish(fmt.Errorf("stream: decode line: %w", uerr))
+				return false
+			}
+			it.value = value
+			if err != nil && err != io.EOF {
+				// the line is returned, the error is reported by the next call
+				it.pending = err
+			}
+			return true
+		}
+
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			it.finish(err)
+			return false
+		}
+	}
+}
+
+// readLine reads until the newline like bufio.Reader.ReadBytes, but no more than the maximum line size.
+func (it *LineIterator[T]) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := it.reader.ReadSlice('\n')
+		if len(line)+len(chunk) > it.maxLine {
+			return nil, ErrLineTooLong
+		}
+		line = append(line, chunk...)
+		if err != bufio.ErrBufferFull {
+			return line, err
+		}
+	}
+}
+
+// Value returns the value decoded by the last call to Next.
+func (it *LineIterator[T]) Value() T {
+	return it.value
+}
+
+// Err returns the first error that was encountered, it is nil at the end of the body.
+func (it *LineIterator[T]) Err() error {
+	if it.done {
+		return it.err
+	}
+	return nil
+}
+
+// Close closes the response body, it is safe to call after the iteration has stopped.
+func (it *LineIterator[T]) Close() error {
+	if it.done {
+		return nil
+	}
+	it.done = true
+	return it.response.Body.Close()
+}
+
+func (it *LineIterator[T]) finish(err error) {
+	if it.err == nil {
+		it.err = err
+	}
+	_ = it.Close()
+}
+
+// DecodeLines decodes each line of a newline-delimited response body into a T and calls fn.
+// Lines are limited to DefaultMaxLineSize, use NewLineIterator and SetMaxLineSize for longer lines.
+// Reading stops when fn returns an error, ErrStopStream stops without error.
+// The response body is always closed when it returns.
+func DecodeLines[T any](response *http.Response, fn func(T) error) error {
+	it := NewLineIterator[T](response)
+	defer it.Close()
+
+	for it.Next() {
+		if err := fn(it.Value()); err != nil {
+			if errors.Is(err, ErrStopStream) {
+				return nil
+			}
+			return err
+		}
+	}
+	return it.Err()
+}
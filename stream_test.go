@@ -0,0 +1,155 @@
+package ghttp
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zdz1715/ghttp/encoding/json"
+)
+
+type streamLine struct {
+	ID int `json:"id"`
+}
+
+func newNDJSONServer(lines int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for i := 1; i <= lines; i++ {
+			_, _ = fmt.Fprintf(w, "{\"id\":%d}\n", i)
+			if i%2 == 0 {
+				_, _ = fmt.Fprint(w, "\r\n")
+			}
+			w.(http.Flusher).Flush()
+		}
+	}))
+}
+
+func TestDecodeLines(t *testing.T) {
+	server := newNDJSONServer(5)
+	defer server.Close()
+
+	// debug must not consume the stream
+	client := NewClient(WithEndpoint(server.URL), WithDebug(func() DebugInterface {
+		return &Debug{Writer: io.Discard}
+	}))
+
+	tests := []struct {
+		stop int
+		want int
+	}{
+		{stop: 0, want: 15},
+		{stop: 2, want: 3},
+	}
+
+	for i, v := range tests {
+		req, _ := http.NewRequest(http.MethodGet, "/logs", nil)
+		response, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sum int
+		err = DecodeLines(response, func(line streamLine) error {
+			sum += line.ID
+			if line.ID == v.stop {
+				return ErrStopStream
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sum != v.want {
+			t.Errorf("index: %d, DecodeLines() failed: target=%d want=%d", i, sum, v.want)
+		}
+	}
+}
+
+func TestLineIterator(t *testing.T) {
+	server := newNDJSONServer(3)
+	defer server.Close()
+
+	client := NewClient(WithEndpoint(server.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/logs", nil)
+	response, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	it := NewLineIterator[*streamLine](response)
+	defer it.Close()
+
+	var ids []int
+	for it.Next() {
+		ids = append(ids, it.Value().ID)
+		cancel()
+	}
+	if len(ids) != 1 || ids[0] != 1 {
+		t.Errorf("LineIterator failed: target=%v want=[1]", ids)
+	}
+	if it.Err() != context.Canceled {
+		t.Errorf("LineIterator.Err() failed: target=%v want=%v", it.Err(), context.Canceled)
+	}
+}
+
+func TestLineIteratorClientCodecs(t *testing.T) {
+	server := newNDJSONServer(2)
+	defer server.Close()
+
+	opts := json.DefaultOptions()
+	opts.UseNumber = true
+	client := NewClient(WithEndpoint(server.URL), WithCodec(json.NewCodec(opts)))
+
+	req, _ := http.NewRequest(http.MethodGet, "/logs", nil)
+	response, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []any
+	err = DecodeLines(response, func(line map[string]any) error {
+		ids = append(ids, line["id"])
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] != stdjson.Number("1") {
+		t.Errorf("DecodeLines() failed: target=%#v want=json.Number", ids)
+	}
+}
+
+func TestLineIteratorMaxLineSize(t *testing.T) {
+	tests := []struct {
+		size int
+		want []int
+		err  error
+	}{
+		{size: 0, want: []int{1, 2}},
+		{size: len("{\"id\":1}\n"), want: []int{1, 2}},
+		{size: len("{\"id\":1}"), err: ErrLineTooLong},
+	}
+
+	for i, v := range tests {
+		response := &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/x-ndjson"}},
+			Body:       io.NopCloser(strings.NewReader("{\"id\":1}\n{\"id\":2}")),
+		}
+		it := NewLineIterator[streamLine](response).SetMaxLineSize(v.size)
+		var ids []int
+		for it.Next() {
+			ids = append(ids, it.Value().ID)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(v.want) || it.Err() != v.err {
+			t.Errorf("index: %d, LineIterator failed: target=%v %v want=%v %v", i, ids, it.Err(), v.want, v.err)
+		}
+	}
+}
@@ -29,27 +29,17 @@ func FullPath(endpoint, path string) string {
 	return fullPath
 }
 
+// ContentSubtype returns the subtype of contentType, or its structured syntax suffix if it has one,
+// e.g. json of application/vnd.api+json.
 func ContentSubtype(contentType string) string {
-	if contentType == "" {
-		return ""
-	}
-	left := strings.Index(contentType, "/")
-	if left == -1 {
-		return ""
-	}
-	right := strings.Index(contentType, ";")
-	if right == -1 {
-		right = len(contentType)
-	}
-	if right < left {
+	m, err := ParseMediaType(contentType)
+	if err != nil {
 		return ""
 	}
-	subContentType := contentType[left+1 : right]
-	left = strings.Index(subContentType, "+")
-	if left >= 0 {
-		return subContentType[left+1:]
+	if suffix := m.Suffix(); suffix != "" {
+		return suffix
 	}
-	return subContentType
+	return m.Subtype
 }
 
 func Not2xxCode(code int) bool {
@@ -92,6 +92,14 @@ func TestContentSubtype(t *testing.T) {
 			contentType: "application/vnd.docker.distribution.manifest.v2+json; charset=utf-8",
 			want:        "json",
 		},
+		{
+			contentType: "Application/JSON",
+			want:        "json",
+		},
+		{
+			contentType: "json",
+			want:        "",
+		},
 	}
 
 	for _, v := range tests {